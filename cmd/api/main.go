@@ -4,12 +4,21 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/ahmadbasyouni10/videogpt/internal/handlers"
+	"github.com/ahmadbasyouni10/videogpt/pkg/chapters"
+	"github.com/ahmadbasyouni10/videogpt/pkg/chat"
+	"github.com/ahmadbasyouni10/videogpt/pkg/embeddings"
 	"github.com/ahmadbasyouni10/videogpt/pkg/ffmpeg"
+	"github.com/ahmadbasyouni10/videogpt/pkg/qa"
+	"github.com/ahmadbasyouni10/videogpt/pkg/ratelimit"
+	"github.com/ahmadbasyouni10/videogpt/pkg/search"
 	"github.com/ahmadbasyouni10/videogpt/pkg/summarization"
 	"github.com/ahmadbasyouni10/videogpt/pkg/supabase"
 	"github.com/ahmadbasyouni10/videogpt/pkg/transcription"
+	"github.com/ahmadbasyouni10/videogpt/pkg/translation"
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -42,13 +51,136 @@ func main() {
 	if openaiApiKey == "" {
 		log.Println("Warning: OPENAI_API_KEY not set, transcription and summarization services will not work")
 	}
-	transcriptionService := transcription.NewWhisperService(openaiApiKey)
+
+	// Client-side rate limits, one limiter per API key shared by every service using it
+	// OPENAI_REQUESTS_PER_MINUTE, OPENAI_TOKENS_PER_MINUTE and OPENAI_MAX_IN_FLIGHT cover the OpenAI key,
+	// other providers read the same settings with their own prefix (DEEPGRAM_, ASSEMBLYAI_, ...)
+	limiters := ratelimit.NewRegistry()
+	openaiLimiter := limiters.For(openaiApiKey, ratelimit.LimitsFromEnv("openai"))
+	providerLimiter := func(config transcription.Config) *ratelimit.Limiter {
+		if config.APIKey == openaiApiKey {
+			return openaiLimiter
+		}
+		return limiters.For(config.APIKey, ratelimit.LimitsFromEnv(config.Provider))
+	}
+
+	// TRANSCRIPTION_PROVIDER picks openai (default), openai-compatible, deepgram, assemblyai,
+	// or whisper.cpp / faster-whisper to run offline (TRANSCRIPTION_BINARY, TRANSCRIPTION_MODEL),
+	// WHISPER_BASE_URL, WHISPER_MODEL, etc. override the defaults of the Whisper-based ones
+	transcriptionConfig := transcription.ConfigFromEnv(openaiApiKey)
+	transcriptionConfig.Limiter = providerLimiter(transcriptionConfig)
+	transcriptionConfig.TempDir = tempDir
+	primaryTranscription, err := transcription.NewService(transcriptionConfig)
+	if err != nil {
+		log.Fatalf("Failed to initialize transcription service: %v", err)
+	}
+
+	// TRANSCRIPTION_FALLBACK lists providers to try when the primary one fails, see FallbackConfigsFromEnv
+	transcriptionProviders := []transcription.Provider{{Name: transcriptionConfig.Provider, Service: primaryTranscription}}
+	for _, config := range transcription.FallbackConfigsFromEnv(openaiApiKey) {
+		config.Limiter = providerLimiter(config)
+		config.TempDir = tempDir
+		service, err := transcription.NewService(config)
+		if err != nil {
+			log.Printf("Warning: skipping transcription fallback %s: %v", config.Provider, err)
+			continue
+		}
+		transcriptionProviders = append(transcriptionProviders, transcription.Provider{Name: config.Provider, Service: service})
+	}
+	transcriptionFallback := transcription.NewFallbackService(transcriptionProviders...)
+
+	var transcriptionService transcription.Service = transcriptionFallback
+
+	// Optional speaker diarization, DIARIZATION_PROVIDER=http needs DIARIZATION_URL
+	switch os.Getenv("DIARIZATION_PROVIDER") {
+	case "http":
+		transcriptionService = transcription.WithDiarizer(transcriptionService, transcription.NewHTTPDiarizer(os.Getenv("DIARIZATION_URL"), os.Getenv("DIARIZATION_API_KEY")))
+	case "mock":
+		transcriptionService = transcription.WithDiarizer(transcriptionService, transcription.NewMockDiarizer())
+	}
 
 	// Initialize summarization service (using the same OpenAI API key)
-	summarizationService := summarization.NewOpenAIService(openaiApiKey)
+	openaiSummarization := summarization.NewOpenAIService(openaiApiKey)
+	openaiSummarization.Client.HTTP.Limiter = openaiLimiter
+	if model := os.Getenv("SUMMARIZATION_MODEL"); model != "" {
+		openaiSummarization.Model = model
+	}
+	if model := os.Getenv("SUMMARIZATION_STRUCTURED_MODEL"); model != "" {
+		openaiSummarization.StructuredModel = model
+	}
+	if value := os.Getenv("SUMMARIZATION_TEMPERATURE"); value != "" {
+		temperature, err := strconv.ParseFloat(value, 64)
+		if err != nil || temperature < 0 || temperature > 2 {
+			log.Fatalf("SUMMARIZATION_TEMPERATURE must be a number between 0 and 2, got %q", value)
+		}
+		openaiSummarization.Temperature = temperature
+	}
+	summarizationProviders := []summarization.Provider{{Name: "openai", Service: openaiSummarization}}
+
+	// SUMMARIZATION_FALLBACK_BASE_URL adds an OpenAI-compatible server to fall back to
+	if baseURL := os.Getenv("SUMMARIZATION_FALLBACK_BASE_URL"); baseURL != "" {
+		fallbackAPIKey := os.Getenv("SUMMARIZATION_FALLBACK_API_KEY")
+		fallbackSummarization := summarization.NewOpenAIService(fallbackAPIKey)
+		fallbackSummarization.Client.BaseURL = strings.TrimRight(baseURL, "/")
+		fallbackSummarization.Client.HTTP.Limiter = limiters.For(fallbackAPIKey, ratelimit.LimitsFromEnv("summarization_fallback"))
+		if model := os.Getenv("SUMMARIZATION_FALLBACK_MODEL"); model != "" {
+			fallbackSummarization.Model = model
+			fallbackSummarization.StructuredModel = model
+		}
+		// self-hosted servers often only have plain JSON mode
+		fallbackSummarization.StructuredOutputs = os.Getenv("SUMMARIZATION_FALLBACK_STRUCTURED_OUTPUTS") == "true"
+		summarizationProviders = append(summarizationProviders, summarization.Provider{Name: "fallback", Service: fallbackSummarization})
+	}
+	summarizationService := summarization.NewFallbackService(summarizationProviders...)
+
+	// Initialize transcript translation service (chat completions, same API key)
+	translationClient := chat.NewClient(openaiApiKey)
+	translationClient.HTTP.Limiter = openaiLimiter
+	translationService := translation.NewChatTranslator(translationClient)
+
+	// Initialize chapter generation (chat completions, same API key)
+	chaptersClient := chat.NewClient(openaiApiKey)
+	chaptersClient.HTTP.Limiter = openaiLimiter
+	chaptersService := chapters.NewChatGenerator(chaptersClient)
+
+	// Initialize question answering over transcripts (chat completions, same API key)
+	qaClient := chat.NewClient(openaiApiKey)
+	qaClient.HTTP.Limiter = openaiLimiter
+	qaService := qa.NewChatService(qaClient, qa.NewLexicalRetriever())
+
+	// Semantic search over transcripts, on when there is an embeddings endpoint to use
+	// EMBEDDINGS_BASE_URL points at any OpenAI-compatible /embeddings server instead of OpenAI
+	var searchService *search.Service
+	embeddingsService := embeddings.NewOpenAIService(openaiApiKey)
+	embeddingsService.HTTP.Limiter = openaiLimiter
+	if baseURL := os.Getenv("EMBEDDINGS_BASE_URL"); baseURL != "" {
+		embeddingsService.BaseURL = strings.TrimRight(baseURL, "/")
+		embeddingsService.APIKey = os.Getenv("EMBEDDINGS_API_KEY")
+		embeddingsService.HTTP.Limiter = limiters.For(embeddingsService.APIKey, ratelimit.LimitsFromEnv("embeddings"))
+	}
+	if model := os.Getenv("EMBEDDINGS_MODEL"); model != "" {
+		embeddingsService.ModelName = model
+	}
+	if embeddingsService.APIKey != "" || embeddingsService.BaseURL != embeddings.DefaultBaseURL {
+		indexPath := os.Getenv("SEARCH_INDEX_PATH")
+		if indexPath == "" {
+			indexPath = filepath.Join("data", "search-index.gob")
+		}
+		index, err := search.OpenIndex(indexPath, embeddingsService.Model())
+		if err != nil {
+			log.Fatalf("Failed to open search index: %v", err)
+		}
+		searchService = search.NewService(embeddingsService, index)
+	} else {
+		log.Println("Warning: no embeddings endpoint configured, search is disabled")
+	}
 
 	// Initialize handlers
-	videoHandler := handlers.NewVideoHandler(supabaseClient, ffmpegProcessor, transcriptionService, summarizationService)
+	videoHandler := handlers.NewVideoHandler(supabaseClient, ffmpegProcessor, transcriptionService, summarizationService, translationService, chaptersService, qaService, searchService)
+	diagnosticsHandler := handlers.NewDiagnosticsHandler(ffmpegProcessor, transcriptionFallback, summarizationService)
+	glossaryHandler := handlers.NewGlossaryHandler(supabaseClient)
+	summaryTemplateHandler := handlers.NewSummaryTemplateHandler(supabaseClient)
+	searchHandler := handlers.NewSearchHandler(searchService)
 
 	// Initialize Echo instance
 	e := echo.New()
@@ -77,11 +209,59 @@ func main() {
 	// Add route to get thumbnail
 	api.GET("/thumbnails/:id", videoHandler.GetThumbnail)
 
+	// Add route to list audio tracks
+	api.GET("/videos/:id/tracks", videoHandler.ListAudioTracks)
+
 	// Add route to generate transcript
 	api.POST("/videos/:id/transcript", videoHandler.GenerateTranscript)
 
+	// Add route to get the stored transcript
+	api.GET("/videos/:id/transcript", videoHandler.GetTranscript)
+
+	// Add route to name the speakers found by diarization
+	api.PUT("/videos/:id/speakers", videoHandler.RenameSpeakers)
+
+	// Add routes to translate speech into an English transcript
+	api.POST("/videos/:id/translation", videoHandler.GenerateTranslation)
+	api.GET("/videos/:id/translation", videoHandler.GetTranslation)
+
+	// Add route to export captions (srt, vtt, ttml, sbv, txt or json)
+	api.GET("/videos/:id/captions.:format", videoHandler.GetCaptions)
+
+	// Add route to import existing captions instead of transcribing
+	api.POST("/videos/:id/captions", videoHandler.ImportCaptions)
+
 	// Add route to generate summary
 	api.POST("/videos/:id/summary", videoHandler.GenerateSummary)
+	api.GET("/videos/:id/summary", videoHandler.GetSummary)
+	api.GET("/videos/:id/summaries", videoHandler.ListSummaries)
+
+	// Chapters from the transcript, POST regenerates and can embed them in the MP4
+	api.GET("/videos/:id/chapters", videoHandler.GetChapters)
+	api.POST("/videos/:id/chapters", videoHandler.GenerateChapters)
+
+	// Ask questions about a video, answers cite transcript timestamps
+	api.POST("/videos/:id/ask", videoHandler.Ask)
+	api.GET("/videos/:id/sessions/:session", videoHandler.GetSession)
+
+	// Semantic search across every video's transcript
+	api.GET("/search", searchHandler.Search)
+	api.POST("/videos/:id/index", videoHandler.IndexVideo)
+	api.POST("/search/reindex", videoHandler.ReindexSearch)
+
+	// Summary prompt templates for the workspace
+	api.GET("/summary-templates", summaryTemplateHandler.ListTemplates)
+	api.PUT("/summary-templates", summaryTemplateHandler.PutTemplates)
+
+	// Glossary routes, scoped to the workspace in the X-Workspace-ID header
+	api.GET("/glossary", glossaryHandler.ListEntries)
+	api.POST("/glossary", glossaryHandler.CreateEntry)
+	api.PUT("/glossary/:id", glossaryHandler.UpdateEntry)
+	api.DELETE("/glossary/:id", glossaryHandler.DeleteEntry)
+
+	// Diagnostics routes
+	api.GET("/diagnostics/ffmpeg", diagnosticsHandler.GetFFmpeg)
+	api.GET("/diagnostics/providers", diagnosticsHandler.GetProviders)
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/supabase"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// sessionsBucket is the storage bucket ask sessions are kept in, one JSON document per session
+const sessionsBucket = "sessions"
+
+// maxQuestionLength keeps a single question from eating the prompt
+const maxQuestionLength = 2000
+
+// saveSession stores the session as JSON in Supabase storage
+func (h *VideoHandler) saveSession(session *models.Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	_, err = h.SupabaseClient.UploadBytes(sessionsBucket, session.ID+".json", data, "application/json")
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// loadSession reads a session back, wrapping supabase.ErrNotFound when there is none for this video
+func (h *VideoHandler) loadSession(videoID string, sessionID string) (*models.Session, error) {
+	// session IDs end up in a storage path, so only accept the UUIDs we hand out
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return nil, fmt.Errorf("invalid session ID: %w", supabase.ErrNotFound)
+	}
+
+	data, err := h.SupabaseClient.DownloadFile(sessionsBucket, sessionID+".json")
+	if err != nil {
+		return nil, err
+	}
+
+	var session models.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", err)
+	}
+	if session.VideoID != videoID {
+		return nil, fmt.Errorf("session belongs to another video: %w", supabase.ErrNotFound)
+	}
+	return &session, nil
+}
+
+// sessionError turns a loadSession error into the matching response
+func sessionError(c echo.Context, err error) error {
+	if errors.Is(err, supabase.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Session not found, leave session_id out to start a new one",
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error":   "Failed to load session",
+		"details": err.Error(),
+	})
+}
+
+// citationLink points at the video at a timestamp using a media fragment
+func citationLink(videoID string, start float64) string {
+	return fmt.Sprintf("/api/videos/%s#t=%.1f", videoID, start)
+}
+
+// askRequest is the body for asking a question
+type askRequest struct {
+	Question  string `json:"question" form:"question"`
+	SessionID string `json:"session_id" form:"session_id"`
+}
+
+// Ask answers a question about the video from its transcript, citing the timestamps it used
+// pass the returned session_id with follow-up questions to keep the conversation going
+func (h *VideoHandler) Ask(c echo.Context) error {
+	videoID := c.Param("id")
+	if videoID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Video ID is required"})
+	}
+
+	var req askRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	req.Question = strings.TrimSpace(req.Question)
+	if req.Question == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Question is required"})
+	}
+	if len(req.Question) > maxQuestionLength {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("Question is too long, keep it under %d characters", maxQuestionLength),
+		})
+	}
+
+	// Continue the session, or start a new one
+	now := time.Now()
+	session := &models.Session{
+		ID:        uuid.New().String(),
+		VideoID:   videoID,
+		Turns:     []models.Turn{},
+		CreatedAt: now,
+	}
+	if req.SessionID != "" {
+		existing, err := h.loadSession(videoID, req.SessionID)
+		if err != nil {
+			return sessionError(c, err)
+		}
+		session = existing
+	}
+
+	transcript, err := h.loadTranscript(videoID, "")
+	if err != nil {
+		return transcriptError(c, err)
+	}
+
+	answer, err := h.QAService.Ask(c.Request().Context(), transcript, req.Question, session.Turns)
+	if err != nil {
+		return providerError(c, "Failed to answer question", err)
+	}
+	for i := range answer.Citations {
+		if answer.Citations[i].End > 0 {
+			answer.Citations[i].Link = citationLink(videoID, answer.Citations[i].Start)
+		}
+	}
+
+	session.Turns = append(session.Turns,
+		models.Turn{Role: "user", Content: req.Question, CreatedAt: now},
+		models.Turn{Role: "assistant", Content: answer.Text, Citations: answer.Citations, CreatedAt: time.Now()},
+	)
+	session.UpdatedAt = time.Now()
+	// the answer is still returned if this fails, the next follow-up just loses the context
+	if err := h.saveSession(session); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":     "success",
+		"video_id":   videoID,
+		"session_id": session.ID,
+		"answer":     answer.Text,
+		"citations":  answer.Citations,
+	})
+}
+
+// GetSession returns a session's questions and answers
+func (h *VideoHandler) GetSession(c echo.Context) error {
+	videoID := c.Param("id")
+	if videoID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Video ID is required"})
+	}
+
+	session, err := h.loadSession(videoID, c.Param("session"))
+	if err != nil {
+		return sessionError(c, err)
+	}
+	return c.JSON(http.StatusOK, session)
+}
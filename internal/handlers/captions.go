@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/captions"
+	"github.com/ahmadbasyouni10/videogpt/pkg/supabase"
+	"github.com/labstack/echo/v4"
+)
+
+// captionOptions reads the line breaking settings from the query string
+// anything missing or invalid falls back to the default
+func captionOptions(c echo.Context) captions.Options {
+	opts := captions.DefaultOptions()
+
+	if value, err := strconv.Atoi(c.QueryParam("max_chars")); err == nil {
+		opts.MaxCharsPerLine = value
+	}
+	if value, err := strconv.Atoi(c.QueryParam("max_lines")); err == nil {
+		opts.MaxLines = value
+	}
+	if value, err := strconv.ParseFloat(c.QueryParam("min_duration"), 64); err == nil {
+		opts.MinDuration = value
+	}
+	if value, err := strconv.ParseFloat(c.QueryParam("max_duration"), 64); err == nil {
+		opts.MaxDuration = value
+	}
+	if value, err := strconv.ParseFloat(c.QueryParam("max_cps"), 64); err == nil {
+		opts.MaxCPS = value
+	}
+
+	return opts
+}
+
+// GetCaptions renders the stored transcript as captions
+// the format comes from the extension (srt, vtt, ttml, sbv, txt or json), "lang" asks
+// for a translation into that language, and max_chars, max_lines, min_duration,
+// max_duration and max_cps tune the line breaking
+func (h *VideoHandler) GetCaptions(c echo.Context) error {
+	videoID := c.Param("id")
+	if videoID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Video ID is required"})
+	}
+
+	format, err := captions.ParseFormat(c.Param("format"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	var transcript *models.Transcript
+	if language := c.QueryParam("lang"); language != "" {
+		transcript, err = h.translatedTranscript(c.Request().Context(), videoID, language)
+		switch {
+		case errors.Is(err, errInvalidLanguage):
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		case err != nil && !errors.Is(err, supabase.ErrNotFound):
+			// a missing transcript is still a 404 below, anything else came from translating it
+			return providerError(c, "Failed to translate transcript", err)
+		}
+	} else {
+		transcript, err = h.loadTranscript(videoID, "")
+	}
+	if err != nil {
+		return transcriptError(c, err)
+	}
+
+	data, err := captions.Render(format, transcript, captionOptions(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error":   "Failed to render captions",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Blob(http.StatusOK, format.ContentType(), data)
+}
+
+// ImportCaptions stores an uploaded SRT or VTT file as the video's transcript
+// imported transcripts are marked with source "imported" and can be summarized
+// straight away without ever calling the transcription service
+func (h *VideoHandler) ImportCaptions(c echo.Context) error {
+	videoID := c.Param("id")
+	if videoID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Video ID is required"})
+	}
+
+	file, fileHeader, err := c.Request().FormFile("captions")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No captions file provided"})
+	}
+	defer file.Close()
+
+	// Use the format field if given, otherwise the file extension
+	formatName := c.FormValue("format")
+	if formatName == "" {
+		formatName = strings.TrimPrefix(filepath.Ext(fileHeader.Filename), ".")
+	}
+	format, err := captions.ParseFormat(formatName)
+	if err != nil || (format != captions.FormatSRT && format != captions.FormatVTT) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unsupported captions format, use srt or vtt"})
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to read captions file"})
+	}
+
+	transcript, err := captions.Parse(format, data)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Failed to parse captions",
+			"details": err.Error(),
+		})
+	}
+	transcript.VideoID = videoID
+	transcript.Language = c.FormValue("language")
+
+	if err := h.saveTranscript(transcript, ""); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error":   "Failed to save transcript",
+			"details": err.Error(),
+		})
+	}
+	h.indexTranscript(transcript)
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"status":     "success",
+		"message":    "Captions imported successfully",
+		"video_id":   videoID,
+		"transcript": transcript,
+	})
+}
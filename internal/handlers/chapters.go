@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/chapters"
+	"github.com/ahmadbasyouni10/videogpt/pkg/ffmpeg"
+	"github.com/ahmadbasyouni10/videogpt/pkg/supabase"
+	"github.com/labstack/echo/v4"
+)
+
+// chaptersBucket is the storage bucket chapter lists are kept in, one JSON document per video
+const chaptersBucket = "chapters"
+
+// saveChapters stores the chapter list as JSON in Supabase storage
+func (h *VideoHandler) saveChapters(list *models.Chapters) error {
+	data, err := json.Marshal(list)
+	if err != nil {
+		return fmt.Errorf("failed to marshal chapters: %w", err)
+	}
+
+	_, err = h.SupabaseClient.UploadBytes(chaptersBucket, list.VideoID+".json", data, "application/json")
+	if err != nil {
+		return fmt.Errorf("failed to save chapters: %w", err)
+	}
+	return nil
+}
+
+// loadChapters reads a stored chapter list back, wrapping supabase.ErrNotFound when there is none
+func (h *VideoHandler) loadChapters(videoID string) (*models.Chapters, error) {
+	data, err := h.SupabaseClient.DownloadFile(chaptersBucket, videoID+".json")
+	if err != nil {
+		return nil, err
+	}
+
+	var list models.Chapters
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode chapters: %w", err)
+	}
+	return &list, nil
+}
+
+// generateChapters chapters the transcript and saves the result
+func (h *VideoHandler) generateChapters(ctx context.Context, transcript *models.Transcript) (*models.Chapters, error) {
+	generated, err := h.ChaptersService.Generate(ctx, transcript)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceCreatedAt := transcript.CreatedAt
+	list := &models.Chapters{
+		VideoID:         transcript.VideoID,
+		Chapters:        generated,
+		CreatedAt:       time.Now(),
+		SourceCreatedAt: &sourceCreatedAt,
+	}
+	if err := h.saveChapters(list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// chaptersError turns a generateChapters error into the matching response
+func chaptersError(c echo.Context, err error) error {
+	if errors.Is(err, chapters.ErrUntimed) {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+			"error":   "Transcript has no timestamps to chapter, regenerate it with timestamps",
+			"details": err.Error(),
+		})
+	}
+	return providerError(c, "Failed to generate chapters", err)
+}
+
+// chaptersResponse writes the chapter list as JSON, or as YouTube description lines for format=youtube
+func chaptersResponse(c echo.Context, list *models.Chapters) error {
+	switch c.QueryParam("format") {
+	case "", "json":
+		return c.JSON(http.StatusOK, list)
+	case "youtube":
+		return c.String(http.StatusOK, chapters.YouTube(list.Chapters))
+	}
+	return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unsupported format, use json or youtube"})
+}
+
+// GetChapters returns the video's chapters, generating them from the stored transcript the first time
+// and again whenever that transcript has been regenerated since
+// pass format=youtube for lines to paste into a YouTube description
+func (h *VideoHandler) GetChapters(c echo.Context) error {
+	videoID := c.Param("id")
+	if videoID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Video ID is required"})
+	}
+
+	transcript, err := h.loadTranscript(videoID, "")
+	if err != nil {
+		return transcriptError(c, err)
+	}
+
+	list, err := h.loadChapters(videoID)
+	if err != nil && !errors.Is(err, supabase.ErrNotFound) {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error":   "Failed to load chapters",
+			"details": err.Error(),
+		})
+	}
+	if err == nil && list.SourceCreatedAt != nil && list.SourceCreatedAt.Equal(transcript.CreatedAt) {
+		return chaptersResponse(c, list)
+	}
+
+	list, err = h.generateChapters(c.Request().Context(), transcript)
+	if err != nil {
+		return chaptersError(c, err)
+	}
+	return chaptersResponse(c, list)
+}
+
+// GenerateChapters regenerates the video's chapters from its stored transcript
+// with embed=true they are also written into the stored MP4 as metadata chapters
+func (h *VideoHandler) GenerateChapters(c echo.Context) error {
+	videoID := c.Param("id")
+	if videoID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Video ID is required"})
+	}
+	ctx := c.Request().Context()
+
+	transcript, err := h.loadTranscript(videoID, "")
+	if err != nil {
+		return transcriptError(c, err)
+	}
+
+	list, err := h.generateChapters(ctx, transcript)
+	if err != nil {
+		return chaptersError(c, err)
+	}
+
+	if c.FormValue("embed") == "true" {
+		if err := h.embedChapters(ctx, list); err != nil {
+			if errors.Is(err, errVideoNotFound) {
+				return downloadError(c, err)
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error":   "Chapters were generated but embedding them in the video failed",
+				"details": err.Error(),
+			})
+		}
+		list.Embedded = true
+		if err := h.saveChapters(list); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+
+	return chaptersResponse(c, list)
+}
+
+// embedChapters writes the chapters into the stored video, replacing it
+func (h *VideoHandler) embedChapters(ctx context.Context, list *models.Chapters) error {
+	videoPath, err := h.downloadVideo(ctx, list.VideoID)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(videoPath)
+
+	markers := make([]ffmpeg.Chapter, len(list.Chapters))
+	for i, chapter := range list.Chapters {
+		markers[i] = ffmpeg.Chapter{Start: chapter.Start, End: chapter.End, Title: chapter.Title}
+	}
+	outputPath, err := h.FFmpegProcessor.EmbedChapters(ctx, videoPath, markers)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(outputPath)
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read chaptered video: %w", err)
+	}
+	// upsert over the original so the video URL stays the same
+	if _, err := h.SupabaseClient.UploadBytes("videos", list.VideoID+".mp4", data, "video/mp4"); err != nil {
+		return fmt.Errorf("failed to upload chaptered video: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ahmadbasyouni10/videogpt/pkg/fallback"
+	"github.com/ahmadbasyouni10/videogpt/pkg/ffmpeg"
+	"github.com/labstack/echo/v4"
+)
+
+// DiagnosticsHandler reports on the health of the services the API depends on
+type DiagnosticsHandler struct {
+	FFmpegProcessor *ffmpeg.Processor
+	Transcription   fallback.Reporter // provider health of the transcription chain, nil if not a chain
+	Summarization   fallback.Reporter
+}
+
+// NewDiagnosticsHandler creates a new diagnostics handler
+func NewDiagnosticsHandler(ffmpegProcessor *ffmpeg.Processor, transcription fallback.Reporter, summarization fallback.Reporter) *DiagnosticsHandler {
+	return &DiagnosticsHandler{
+		FFmpegProcessor: ffmpegProcessor,
+		Transcription:   transcription,
+		Summarization:   summarization,
+	}
+}
+
+// GetFFmpeg returns the capabilities detected for the installed ffmpeg build
+func (h *DiagnosticsHandler) GetFFmpeg(c echo.Context) error {
+	caps := h.FFmpegProcessor.Capabilities
+	if caps == nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "FFmpeg capabilities have not been detected"})
+	}
+
+	// summarize the features that depend on optional parts of the build, as the processor decides them
+	audioCodec, _ := h.FFmpegProcessor.AudioCodec()
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"capabilities": caps,
+		"features": map[string]interface{}{
+			"audio_codec":    audioCodec,
+			"duration_probe": h.FFmpegProcessor.DurationProbe(),
+			"thumbnails":     h.FFmpegProcessor.CanCreateThumbnails(),
+		},
+	})
+}
+
+// GetProviders returns the circuit breaker state of every AI provider, in fallback order
+// the status is 503 when a whole chain is unavailable so it can back a health check
+func (h *DiagnosticsHandler) GetProviders(c echo.Context) error {
+	chains := map[string]fallback.Reporter{
+		"transcription": h.Transcription,
+		"summarization": h.Summarization,
+	}
+
+	status := http.StatusOK
+	response := map[string]interface{}{}
+	for name, reporter := range chains {
+		if reporter == nil {
+			continue
+		}
+		health := reporter.Health()
+		response[name] = health
+
+		available := false
+		for _, provider := range health {
+			if provider.State != fallback.StateOpen {
+				available = true
+			}
+		}
+		if !available {
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	return c.JSON(status, response)
+}
@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/glossary"
+	"github.com/ahmadbasyouni10/videogpt/pkg/supabase"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// glossariesBucket is the storage bucket glossaries are kept in, one JSON document per workspace
+const glossariesBucket = "glossaries"
+
+// defaultWorkspace is used when a request doesn't name a workspace
+const defaultWorkspace = "default"
+
+// workspacePattern keeps workspace IDs safe to use as a storage path
+var workspacePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// errInvalidWorkspace is returned for workspace IDs we won't use as a storage path
+var errInvalidWorkspace = errors.New("invalid workspace")
+
+// workspaceID reads the workspace from the X-Workspace-ID header or the workspace query param
+func workspaceID(c echo.Context) (string, error) {
+	workspace := c.Request().Header.Get("X-Workspace-ID")
+	if workspace == "" {
+		workspace = c.QueryParam("workspace")
+	}
+	if workspace == "" {
+		return defaultWorkspace, nil
+	}
+	if !workspacePattern.MatchString(workspace) {
+		return "", fmt.Errorf("%w: invalid workspace ID %q", errInvalidWorkspace, workspace)
+	}
+	return workspace, nil
+}
+
+// loadGlossary reads a workspace's glossary, a workspace without one has an empty glossary
+func loadGlossary(client *supabase.Client, workspace string) (glossary.Glossary, error) {
+	data, err := client.DownloadFile(glossariesBucket, workspace+".json")
+	if errors.Is(err, supabase.ErrNotFound) {
+		return glossary.Glossary{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries glossary.Glossary
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode glossary: %w", err)
+	}
+	return entries, nil
+}
+
+// saveGlossary writes a workspace's glossary back to storage
+func saveGlossary(client *supabase.Client, workspace string, entries glossary.Glossary) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal glossary: %w", err)
+	}
+	if _, err := client.UploadBytes(glossariesBucket, workspace+".json", data, "application/json"); err != nil {
+		return fmt.Errorf("failed to save glossary: %w", err)
+	}
+	return nil
+}
+
+// workspaceGlossary loads the glossary for the request's workspace
+// a bad workspace ID wraps errInvalidWorkspace
+func (h *VideoHandler) workspaceGlossary(c echo.Context) (glossary.Glossary, error) {
+	workspace, err := workspaceID(c)
+	if err != nil {
+		return nil, err
+	}
+	return loadGlossary(h.SupabaseClient, workspace)
+}
+
+// glossaryError turns a workspaceGlossary error into the matching response
+func glossaryError(c echo.Context, err error) error {
+	if errors.Is(err, errInvalidWorkspace) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error":   "Failed to load glossary",
+		"details": err.Error(),
+	})
+}
+
+// GlossaryHandler manages each workspace's transcription glossary
+// a glossary is one document, so edits to a workspace's glossary are serialized
+// to keep concurrent edits from overwriting each other (within this instance)
+type GlossaryHandler struct {
+	SupabaseClient *supabase.Client
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// NewGlossaryHandler creates a new glossary handler
+func NewGlossaryHandler(supabaseClient *supabase.Client) *GlossaryHandler {
+	return &GlossaryHandler{
+		SupabaseClient: supabaseClient,
+		locks:          map[string]*sync.Mutex{},
+	}
+}
+
+// lock takes the workspace's edit lock, call the returned function to release it
+func (h *GlossaryHandler) lock(workspace string) func() {
+	h.mu.Lock()
+	lock, ok := h.locks[workspace]
+	if !ok {
+		lock = &sync.Mutex{}
+		h.locks[workspace] = lock
+	}
+	h.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// glossaryRequest is the body for creating or updating an entry
+type glossaryRequest struct {
+	Term              string   `json:"term"`
+	Aliases           []string `json:"aliases"`
+	PreferredSpelling string   `json:"preferred_spelling"`
+}
+
+// bindEntry reads and validates a glossary entry from the request body
+func bindEntry(c echo.Context) (*glossaryRequest, error) {
+	var req glossaryRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, errors.New("invalid request body")
+	}
+	req.Term = strings.TrimSpace(req.Term)
+	req.PreferredSpelling = strings.TrimSpace(req.PreferredSpelling)
+	if req.Term == "" {
+		return nil, errors.New("term is required")
+	}
+
+	aliases := req.Aliases[:0]
+	for _, alias := range req.Aliases {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			aliases = append(aliases, alias)
+		}
+	}
+	req.Aliases = aliases
+	return &req, nil
+}
+
+// ListEntries returns the workspace's glossary
+func (h *GlossaryHandler) ListEntries(c echo.Context) error {
+	workspace, err := workspaceID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	entries, err := loadGlossary(h.SupabaseClient, workspace)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error":   "Failed to load glossary",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"workspace": workspace,
+		"entries":   entries,
+	})
+}
+
+// CreateEntry adds a term to the workspace's glossary
+func (h *GlossaryHandler) CreateEntry(c echo.Context) error {
+	workspace, err := workspaceID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	req, err := bindEntry(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	unlock := h.lock(workspace)
+	defer unlock()
+
+	entries, err := loadGlossary(h.SupabaseClient, workspace)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error":   "Failed to load glossary",
+			"details": err.Error(),
+		})
+	}
+
+	now := time.Now()
+	entry := models.GlossaryEntry{
+		ID:                uuid.New().String(),
+		Term:              req.Term,
+		Aliases:           req.Aliases,
+		PreferredSpelling: req.PreferredSpelling,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	entries = append(entries, entry)
+
+	if err := saveGlossary(h.SupabaseClient, workspace, entries); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error":   "Failed to save glossary",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, entry)
+}
+
+// UpdateEntry replaces a glossary entry
+func (h *GlossaryHandler) UpdateEntry(c echo.Context) error {
+	workspace, err := workspaceID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	req, err := bindEntry(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	unlock := h.lock(workspace)
+	defer unlock()
+
+	entries, err := loadGlossary(h.SupabaseClient, workspace)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error":   "Failed to load glossary",
+			"details": err.Error(),
+		})
+	}
+
+	entryID := c.Param("id")
+	for i := range entries {
+		if entries[i].ID != entryID {
+			continue
+		}
+		entries[i].Term = req.Term
+		entries[i].Aliases = req.Aliases
+		entries[i].PreferredSpelling = req.PreferredSpelling
+		entries[i].UpdatedAt = time.Now()
+
+		if err := saveGlossary(h.SupabaseClient, workspace, entries); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error":   "Failed to save glossary",
+				"details": err.Error(),
+			})
+		}
+		return c.JSON(http.StatusOK, entries[i])
+	}
+
+	return c.JSON(http.StatusNotFound, map[string]string{"error": "Glossary entry not found"})
+}
+
+// DeleteEntry removes a glossary entry
+func (h *GlossaryHandler) DeleteEntry(c echo.Context) error {
+	workspace, err := workspaceID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	unlock := h.lock(workspace)
+	defer unlock()
+
+	entries, err := loadGlossary(h.SupabaseClient, workspace)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error":   "Failed to load glossary",
+			"details": err.Error(),
+		})
+	}
+
+	entryID := c.Param("id")
+	for i := range entries {
+		if entries[i].ID != entryID {
+			continue
+		}
+		entries = append(entries[:i], entries[i+1:]...)
+
+		if err := saveGlossary(h.SupabaseClient, workspace, entries); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error":   "Failed to save glossary",
+				"details": err.Error(),
+			})
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	return c.JSON(http.StatusNotFound, map[string]string{"error": "Glossary entry not found"})
+}
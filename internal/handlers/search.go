@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/search"
+	"github.com/ahmadbasyouni10/videogpt/pkg/supabase"
+	"github.com/labstack/echo/v4"
+)
+
+// indexTimeout bounds background indexing, long transcripts take a few embedding requests
+const indexTimeout = 5 * time.Minute
+
+// indexTranscript adds a video's main transcript to the search index in the background,
+// so transcription doesn't wait on it; failures are only logged, POST /videos/:id/index retries
+func (h *VideoHandler) indexTranscript(transcript *models.Transcript) {
+	if h.SearchService == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), indexTimeout)
+		defer cancel()
+
+		if err := h.SearchService.IndexTranscript(ctx, transcript); err != nil && !errors.Is(err, search.ErrEmptyTranscript) {
+			fmt.Printf("Warning: failed to index transcript of video %s for search: %v\n", transcript.VideoID, err)
+		}
+	}()
+}
+
+// IndexVideo (re)indexes a video's stored transcript for search, for videos transcribed
+// before search was set up or whose background indexing failed
+func (h *VideoHandler) IndexVideo(c echo.Context) error {
+	videoID := c.Param("id")
+	if videoID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Video ID is required"})
+	}
+	if h.SearchService == nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "Search is not configured"})
+	}
+
+	transcript, err := h.loadTranscript(videoID, "")
+	if err != nil {
+		return transcriptError(c, err)
+	}
+
+	if err := h.SearchService.IndexTranscript(c.Request().Context(), transcript); err != nil {
+		if errors.Is(err, search.ErrEmptyTranscript) {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		}
+		return providerError(c, "Failed to index transcript", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":   "success",
+		"message":  "Transcript indexed for search",
+		"video_id": videoID,
+	})
+}
+
+// ReindexSearch reindexes, in the background, the videos that were indexed with an earlier
+// embeddings model; they drop out of search results when EMBEDDINGS_MODEL changes until this runs
+func (h *VideoHandler) ReindexSearch(c echo.Context) error {
+	if h.SearchService == nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "Search is not configured"})
+	}
+
+	videoIDs := h.SearchService.Index.Stale()
+	go func() {
+		for _, videoID := range videoIDs {
+			// a request made while this one runs may already have done it
+			if !h.SearchService.Index.IsStale(videoID) {
+				continue
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), indexTimeout)
+			transcript, err := h.loadTranscript(videoID, "")
+			if err == nil {
+				err = h.SearchService.IndexTranscript(ctx, transcript)
+			}
+			cancel()
+
+			if errors.Is(err, supabase.ErrNotFound) || errors.Is(err, search.ErrEmptyTranscript) {
+				// nothing left to index, so it shouldn't stay stale
+				err = h.SearchService.Index.Remove(videoID)
+			}
+			if err != nil {
+				fmt.Printf("Warning: failed to reindex video %s for search: %v\n", videoID, err)
+			}
+		}
+	}()
+
+	return c.JSON(http.StatusAccepted, map[string]interface{}{
+		"status":    "reindexing",
+		"model":     h.SearchService.Index.Model(),
+		"video_ids": videoIDs,
+	})
+}
+
+// SearchHandler searches across every indexed video
+type SearchHandler struct {
+	SearchService *search.Service
+}
+
+// NewSearchHandler creates a new search handler, a nil service means search is switched off
+func NewSearchHandler(searchService *search.Service) *SearchHandler {
+	return &SearchHandler{
+		SearchService: searchService,
+	}
+}
+
+// searchPassage is a search result passage with a link to its moment in the video
+type searchPassage struct {
+	search.PassageResult
+	Link string `json:"link,omitempty"`
+}
+
+// searchResult is a matching video in the search response
+type searchResult struct {
+	VideoID  string          `json:"video_id"`
+	Score    float64         `json:"score"`
+	Passages []searchPassage `json:"passages"`
+}
+
+// Search returns the videos whose transcripts best match q, with their matching passages
+// pass limit for the number of videos, 10 by default and at most 50
+func (h *SearchHandler) Search(c echo.Context) error {
+	if h.SearchService == nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "Search is not configured"})
+	}
+
+	query := c.QueryParam("q")
+	if query == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Query parameter q is required"})
+	}
+	limit := 10
+	if value := c.QueryParam("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 50 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "limit must be a number between 1 and 50"})
+		}
+		limit = parsed
+	}
+
+	videos, err := h.SearchService.Search(c.Request().Context(), query, limit)
+	if err != nil {
+		return providerError(c, "Failed to search", err)
+	}
+
+	results := make([]searchResult, len(videos))
+	for i, video := range videos {
+		passages := make([]searchPassage, len(video.Passages))
+		for j, passage := range video.Passages {
+			passages[j] = searchPassage{PassageResult: passage}
+			if passage.End > 0 {
+				passages[j].Link = citationLink(video.VideoID, passage.Start)
+			}
+		}
+		results[i] = searchResult{VideoID: video.VideoID, Score: video.Score, Passages: passages}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"query":   query,
+		"results": results,
+	})
+}
@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/summarization"
+	"github.com/ahmadbasyouni10/videogpt/pkg/supabase"
+	"github.com/labstack/echo/v4"
+)
+
+// summariesBucket is the storage bucket summaries are kept in, one JSON document per video and style
+const summariesBucket = "summaries"
+
+// summaryPath is where a video's summary in a style lives in the bucket
+// the concise style keeps the original <id>.json name, and a template used in its own shape
+// (no style) is kept per template, so it never overwrites a styled summary
+func summaryPath(videoID string, style string, templateID string) string {
+	switch style {
+	case summarization.StyleConcise:
+		return videoID + ".json"
+	case "":
+		return fmt.Sprintf("%s.tmpl-%s.json", videoID, templateID)
+	}
+	return fmt.Sprintf("%s.%s.json", videoID, style)
+}
+
+// summaryTemplatesBucket keeps each workspace's prompt templates, one JSON document per workspace
+const summaryTemplatesBucket = "summary-templates"
+
+// workspaceTemplates is a workspace's own summary templates
+// Default is the template used when a request doesn't pick one, empty for the builtin default
+type workspaceTemplates struct {
+	Default   string                   `json:"default,omitempty"`
+	Templates []summarization.Template `json:"templates"`
+}
+
+// find returns the workspace template with the given ID
+func (w *workspaceTemplates) find(id string) (*summarization.Template, bool) {
+	for i := range w.Templates {
+		if w.Templates[i].ID == id {
+			return &w.Templates[i], true
+		}
+	}
+	return nil, false
+}
+
+// loadTemplates reads a workspace's templates, a workspace without any has none
+func loadTemplates(client *supabase.Client, workspace string) (*workspaceTemplates, error) {
+	data, err := client.DownloadFile(summaryTemplatesBucket, workspace+".json")
+	if errors.Is(err, supabase.ErrNotFound) {
+		return &workspaceTemplates{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var templates workspaceTemplates
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("failed to decode summary templates: %w", err)
+	}
+	return &templates, nil
+}
+
+// saveTemplates writes a workspace's templates back to storage
+func saveTemplates(client *supabase.Client, workspace string, templates *workspaceTemplates) error {
+	data, err := json.Marshal(templates)
+	if err != nil {
+		return fmt.Errorf("failed to marshal summary templates: %w", err)
+	}
+	if _, err := client.UploadBytes(summaryTemplatesBucket, workspace+".json", data, "application/json"); err != nil {
+		return fmt.Errorf("failed to save summary templates: %w", err)
+	}
+	return nil
+}
+
+// errUnknownTemplate is returned when a request names a template that doesn't exist
+var errUnknownTemplate = errors.New("unknown summary template")
+
+// summaryTemplate picks the template for a summary request: the one asked for, else the
+// workspace's default, else the builtin default; workspace templates shadow builtins with the same ID
+func (h *VideoHandler) summaryTemplate(workspace string, id string) (*summarization.Template, error) {
+	templates, err := loadTemplates(h.SupabaseClient, workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	if id == "" {
+		id = templates.Default
+	}
+	if id == "" {
+		id = summarization.DefaultTemplate.ID
+	}
+	if tmpl, ok := templates.find(id); ok {
+		return tmpl, nil
+	}
+	if tmpl, ok := summarization.BuiltinTemplates[id]; ok {
+		return &tmpl, nil
+	}
+	return nil, fmt.Errorf("%w %q", errUnknownTemplate, id)
+}
+
+// summaryOptions reads the style, model, sampling and template variables from the request
+func summaryOptions(c echo.Context) (summarization.Options, error) {
+	opts := summarization.Options{
+		Style:       c.FormValue("style"),
+		Length:      c.FormValue("length"),
+		Model:       c.FormValue("model"),
+		Title:       c.FormValue("title"),
+		Description: c.FormValue("description"),
+	}
+
+	if value := c.FormValue("temperature"); value != "" {
+		temperature, err := strconv.ParseFloat(value, 64)
+		if err != nil || temperature < 0 || temperature > 2 {
+			return opts, errors.New("temperature must be a number between 0 and 2")
+		}
+		opts.Temperature = &temperature
+	}
+	if value := c.FormValue("top_p"); value != "" {
+		topP, err := strconv.ParseFloat(value, 64)
+		if err != nil || topP <= 0 || topP > 1 {
+			return opts, errors.New("top_p must be a number between 0 and 1")
+		}
+		opts.TopP = &topP
+	}
+
+	// check them here so a typo is a 400 rather than a provider error
+	if opts.Style != "" || opts.Length != "" {
+		if _, _, err := summarization.NormalizeStyle(opts.Style, opts.Length); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// saveSummary stores the summary as JSON in Supabase storage
+func (h *VideoHandler) saveSummary(summary *models.Summary) error {
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("failed to marshal summary: %w", err)
+	}
+
+	_, err = h.SupabaseClient.UploadBytes(summariesBucket, summaryPath(summary.VideoID, summary.Style, summary.Template), data, "application/json")
+	if err != nil {
+		return fmt.Errorf("failed to save summary: %w", err)
+	}
+	return nil
+}
+
+// loadSummary reads a stored summary back, wrapping supabase.ErrNotFound when there is none
+// templateID only matters for the summaries made in a template's own shape, when style is empty
+func (h *VideoHandler) loadSummary(videoID string, style string, templateID string) (*models.Summary, error) {
+	data, err := h.SupabaseClient.DownloadFile(summariesBucket, summaryPath(videoID, style, templateID))
+	if err != nil {
+		return nil, err
+	}
+
+	var summary models.Summary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, fmt.Errorf("failed to decode summary: %w", err)
+	}
+	return &summary, nil
+}
+
+// GetSummary returns the stored summary for a video, with the template and settings it was made with
+// pass "style" to get the summary in that style, or "template" alone for the summary made in that
+// template's own shape; the concise one is returned otherwise
+func (h *VideoHandler) GetSummary(c echo.Context) error {
+	videoID := c.Param("id")
+	if videoID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Video ID is required"})
+	}
+
+	style := c.QueryParam("style")
+	templateID := c.QueryParam("template")
+	if style != "" || templateID == "" {
+		var err error
+		if style, _, err = summarization.NormalizeStyle(style, ""); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	} else {
+		// only a template that exists can have a summary, which also keeps the ID safe as a path
+		workspace, err := workspaceID(c)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		if _, err := h.summaryTemplate(workspace, templateID); errors.Is(err, errUnknownTemplate) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		} else if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error":   "Failed to load summary templates",
+				"details": err.Error(),
+			})
+		}
+	}
+
+	summary, err := h.loadSummary(videoID, style, templateID)
+	if errors.Is(err, supabase.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Summary not found, generate one first"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error":   "Failed to load summary",
+			"details": err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, summary)
+}
+
+// ListSummaries returns every stored summary of a video, keyed by style, and the summaries made
+// in a template's own shape keyed by template, for the builtin and the workspace's templates
+func (h *VideoHandler) ListSummaries(c echo.Context) error {
+	videoID := c.Param("id")
+	if videoID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Video ID is required"})
+	}
+	workspace, err := workspaceID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	templates, err := loadTemplates(h.SupabaseClient, workspace)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error":   "Failed to load summary templates",
+			"details": err.Error(),
+		})
+	}
+
+	templateIDs := map[string]bool{}
+	for id := range summarization.BuiltinTemplates {
+		templateIDs[id] = true
+	}
+	for _, tmpl := range templates.Templates {
+		templateIDs[tmpl.ID] = true
+	}
+
+	// a style, or a template's own shape when style is empty
+	type key struct{ style, template string }
+	var keys []key
+	for _, style := range summarization.Styles {
+		keys = append(keys, key{style: style})
+	}
+	for id := range templateIDs {
+		keys = append(keys, key{template: id})
+	}
+
+	summaries := map[string]*models.Summary{}
+	byTemplate := map[string]*models.Summary{}
+	for _, k := range keys {
+		summary, err := h.loadSummary(videoID, k.style, k.template)
+		if errors.Is(err, supabase.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error":   "Failed to load summaries",
+				"details": err.Error(),
+			})
+		}
+
+		if k.style == "" {
+			byTemplate[k.template] = summary
+		} else {
+			summaries[k.style] = summary
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"video_id":  videoID,
+		"styles":    summarization.Styles,
+		"lengths":   summarization.Lengths,
+		"summaries": summaries,
+		"templates": byTemplate,
+	})
+}
+
+// SummaryTemplateHandler manages each workspace's summary prompt templates
+type SummaryTemplateHandler struct {
+	SupabaseClient *supabase.Client
+}
+
+// NewSummaryTemplateHandler creates a new summary template handler
+func NewSummaryTemplateHandler(supabaseClient *supabase.Client) *SummaryTemplateHandler {
+	return &SummaryTemplateHandler{
+		SupabaseClient: supabaseClient,
+	}
+}
+
+// ListTemplates returns the builtin templates and the workspace's own
+func (h *SummaryTemplateHandler) ListTemplates(c echo.Context) error {
+	workspace, err := workspaceID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	templates, err := loadTemplates(h.SupabaseClient, workspace)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error":   "Failed to load summary templates",
+			"details": err.Error(),
+		})
+	}
+
+	builtins := make([]summarization.Template, 0, len(summarization.BuiltinTemplates))
+	for _, tmpl := range summarization.BuiltinTemplates {
+		builtins = append(builtins, tmpl)
+	}
+	sort.Slice(builtins, func(i, j int) bool { return builtins[i].ID < builtins[j].ID })
+
+	defaultID := templates.Default
+	if defaultID == "" {
+		defaultID = summarization.DefaultTemplate.ID
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"workspace": workspace,
+		"default":   defaultID,
+		"builtin":   builtins,
+		"templates": templates.Templates,
+	})
+}
+
+// PutTemplates replaces the workspace's templates and default
+// every template must render, IDs must be unique, and the default must exist
+func (h *SummaryTemplateHandler) PutTemplates(c echo.Context) error {
+	workspace, err := workspaceID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	var req workspaceTemplates
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+
+	seen := map[string]bool{}
+	for _, tmpl := range req.Templates {
+		if err := tmpl.Validate(); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		if seen[tmpl.ID] {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("template %q is listed twice", tmpl.ID)})
+		}
+		seen[tmpl.ID] = true
+	}
+	if _, builtin := summarization.BuiltinTemplates[req.Default]; req.Default != "" && !seen[req.Default] && !builtin {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("default template %q doesn't exist", req.Default)})
+	}
+	if req.Templates == nil {
+		req.Templates = []summarization.Template{}
+	}
+
+	if err := saveTemplates(h.SupabaseClient, workspace, &req); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error":   "Failed to save summary templates",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"workspace": workspace,
+		"default":   req.Default,
+		"templates": req.Templates,
+	})
+}
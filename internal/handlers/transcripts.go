@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/supabase"
+	"github.com/labstack/echo/v4"
+)
+
+// transcriptsBucket is the storage bucket transcripts are kept in, one JSON document per video
+const transcriptsBucket = "transcripts"
+
+// transcriptPath is where a video's transcript lives in the bucket
+// variant is empty for the main transcript, otherwise it is added to the name (e.g. "a1" for audio track 1)
+func transcriptPath(videoID string, variant string) string {
+	if variant == "" {
+		return videoID + ".json"
+	}
+	return fmt.Sprintf("%s.%s.json", videoID, variant)
+}
+
+// saveTranscript stores the transcript as JSON in Supabase storage
+func (h *VideoHandler) saveTranscript(transcript *models.Transcript, variant string) error {
+	data, err := json.Marshal(transcript)
+	if err != nil {
+		return fmt.Errorf("failed to marshal transcript: %w", err)
+	}
+
+	_, err = h.SupabaseClient.UploadBytes(transcriptsBucket, transcriptPath(transcript.VideoID, variant), data, "application/json")
+	if err != nil {
+		return fmt.Errorf("failed to save transcript: %w", err)
+	}
+	return nil
+}
+
+// loadTranscript reads a stored transcript back, wrapping supabase.ErrNotFound when there is none
+func (h *VideoHandler) loadTranscript(videoID string, variant string) (*models.Transcript, error) {
+	data, err := h.SupabaseClient.DownloadFile(transcriptsBucket, transcriptPath(videoID, variant))
+	if err != nil {
+		return nil, err
+	}
+
+	var transcript models.Transcript
+	if err := json.Unmarshal(data, &transcript); err != nil {
+		return nil, fmt.Errorf("failed to decode transcript: %w", err)
+	}
+	return &transcript, nil
+}
+
+// transcriptError turns a loadTranscript error into the matching response
+func transcriptError(c echo.Context, err error) error {
+	if errors.Is(err, supabase.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Transcript not found, generate one first",
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error":   "Failed to load transcript",
+		"details": err.Error(),
+	})
+}
+
+// GetTranscript returns the stored transcript for a video
+// pass "track" to get the transcript of a specific audio track
+func (h *VideoHandler) GetTranscript(c echo.Context) error {
+	videoID := c.Param("id")
+	if videoID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Video ID is required"})
+	}
+
+	variant := ""
+	if track := c.QueryParam("track"); track != "" {
+		// the track ends up in the storage path, so only a plain index is allowed
+		index, err := strconv.Atoi(track)
+		if err != nil || index < 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "track must be a non-negative number"})
+		}
+		variant = fmt.Sprintf("a%d", index)
+	}
+
+	transcript, err := h.loadTranscript(videoID, variant)
+	if err != nil {
+		return transcriptError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, transcript)
+}
+
+// speakersRequest maps speaker IDs to the names they should be shown with
+type speakersRequest struct {
+	Speakers map[string]string `json:"speakers"`
+}
+
+// RenameSpeakers sets display names for the diarized speakers of a video
+// e.g. {"speakers": {"SPEAKER_00": "Alice"}}, an empty name resets it to the ID
+// the main transcript is the only source of names: translations of it are cached without
+// them and take the current ones when read, while per-track transcripts (?track=) and the
+// Whisper translation were diarized on their own, so their speaker IDs don't line up and
+// they keep showing the IDs
+func (h *VideoHandler) RenameSpeakers(c echo.Context) error {
+	videoID := c.Param("id")
+	if videoID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Video ID is required"})
+	}
+
+	var req speakersRequest
+	if err := c.Bind(&req); err != nil || len(req.Speakers) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Speakers are required"})
+	}
+
+	transcript, err := h.loadTranscript(videoID, "")
+	if err != nil {
+		return transcriptError(c, err)
+	}
+
+	for speaker, name := range req.Speakers {
+		if _, ok := transcript.Speakers[speaker]; !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("Unknown speaker %q", speaker),
+			})
+		}
+		if name = strings.TrimSpace(name); name == "" {
+			name = speaker
+		}
+		transcript.Speakers[speaker] = name
+	}
+
+	if err := h.saveTranscript(transcript, ""); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error":   "Failed to save transcript",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":   "success",
+		"video_id": videoID,
+		"speakers": transcript.Speakers,
+	})
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/supabase"
+	"github.com/ahmadbasyouni10/videogpt/pkg/transcription"
+	"github.com/labstack/echo/v4"
+)
+
+// translationVariant is the storage variant English translations are saved under
+const translationVariant = "en"
+
+// GenerateTranslation translates a video's speech into an English transcript
+// it takes the same track and Whisper form values as GenerateTranscript, and the
+// result is stored next to the original-language transcript with the detected language
+func (h *VideoHandler) GenerateTranslation(c echo.Context) error {
+	videoID := c.Param("id")
+	if videoID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Video ID is required"})
+	}
+
+	ctx := c.Request().Context()
+	tempFilePath, err := h.downloadVideo(ctx, videoID)
+	if err != nil {
+		return downloadError(c, err)
+	}
+	defer os.Remove(tempFilePath) // Clean up when done
+
+	tracks, err := h.FFmpegProcessor.ListAudioTracks(ctx, tempFilePath)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error":   "Failed to list audio tracks",
+			"details": err.Error(),
+		})
+	}
+	if len(tracks) == 0 {
+		return noSpeechResponse(c, videoID, "Video has no audio track")
+	}
+
+	// only one translation is kept per video, so all_tracks doesn't apply here
+	selected, err := selectTracks(c, tracks)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	track := selected[0]
+
+	opts, err := transcriptionOptions(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	// Steer Whisper towards the workspace's spellings, then fix whatever it still gets wrong
+	terms, err := h.workspaceGlossary(c)
+	if err != nil {
+		return glossaryError(c, err)
+	}
+	opts.Vocabulary = terms.Terms() // listed after the request or WHISPER_PROMPT prompt
+
+	request := trackRequest{
+		Options: opts,
+		Task:    taskTranslate,
+		Diarize: c.FormValue("diarize") == "true",
+	}
+
+	result, err := h.transcribeTrack(ctx, videoID, tempFilePath, track, request)
+	if errors.Is(err, transcription.ErrTranslationUnsupported) || errors.Is(err, transcription.ErrDiarizationUnsupported) {
+		return c.JSON(http.StatusNotImplemented, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return providerError(c, "Failed to translate audio", err)
+	}
+	if result.Status == "no_speech" {
+		return noSpeechResponse(c, videoID, result.Message)
+	}
+
+	translation := result.Transcript
+	terms.Apply(translation)
+	if translation.SourceLanguage == "" {
+		// fall back to the original transcript or the track tag for the detected language
+		if original, err := h.loadTranscript(videoID, ""); err == nil && original.Language != "" {
+			translation.SourceLanguage = original.Language
+		} else {
+			translation.SourceLanguage = track.Language
+		}
+	}
+
+	if err := h.saveTranscript(translation, translationVariant); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error":   "Failed to save translation",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":          "success",
+		"message":         "Audio translated successfully",
+		"video_id":        videoID,
+		"track":           track,
+		"source_language": translation.SourceLanguage,
+		"translation":     translation,
+	})
+}
+
+// GetTranslation returns the stored English translation for a video
+func (h *VideoHandler) GetTranslation(c echo.Context) error {
+	videoID := c.Param("id")
+	if videoID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Video ID is required"})
+	}
+
+	translation, err := h.loadTranscript(videoID, translationVariant)
+	if err != nil {
+		return transcriptError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, translation)
+}
+
+// languagePattern accepts language codes like "es", "deu" or "pt-BR"
+var languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z]{2,4})?$`)
+
+// translationCacheVariant is the storage variant a translation into language is cached under
+// the prefix keeps it apart from the main variants, "en" is already the Whisper translation
+func translationCacheVariant(language string) string {
+	return "tr-" + language
+}
+
+// translatedTranscript returns the video's transcript in the given language
+// translations are cached in storage per (video, language), so each one is only paid for once
+// until the transcript they were made from is regenerated
+func (h *VideoHandler) translatedTranscript(ctx context.Context, videoID string, language string) (*models.Transcript, error) {
+	if !languagePattern.MatchString(language) {
+		return nil, fmt.Errorf("%w: invalid language code %q", errInvalidLanguage, language)
+	}
+
+	original, err := h.loadTranscript(videoID, "")
+	if err != nil {
+		return nil, err
+	}
+	// nothing to do when the video is already in that language, Whisper reports "english" rather than "en"
+	if models.LanguageCode(original.Language) == models.LanguageCode(language) {
+		return original, nil
+	}
+
+	cached, err := h.loadTranscript(videoID, translationCacheVariant(language))
+	if err != nil && !errors.Is(err, supabase.ErrNotFound) {
+		return nil, err
+	}
+	if err == nil && cached.SourceCreatedAt != nil && cached.SourceCreatedAt.Equal(original.CreatedAt) {
+		// speaker names only live on the main transcript, see RenameSpeakers
+		cached.Speakers = original.Speakers
+		return cached, nil
+	}
+
+	translated, err := h.TranslationService.TranslateTranscript(ctx, original, language)
+	if err != nil {
+		return nil, fmt.Errorf("failed to translate transcript: %w", err)
+	}
+
+	// cache it without names so a stale copy of them is never stored
+	uncached := *translated
+	uncached.Speakers = nil
+	if err := h.saveTranscript(&uncached, translationCacheVariant(language)); err != nil {
+		// the caller still gets the translation, it just isn't cached
+		fmt.Printf("Failed to cache translation: %v\n", err)
+	}
+	return translated, nil
+}
+
+// errInvalidLanguage is returned for language codes we won't use as a storage key
+var errInvalidLanguage = errors.New("invalid language")
@@ -1,19 +1,30 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/chapters"
+	"github.com/ahmadbasyouni10/videogpt/pkg/fallback"
 	"github.com/ahmadbasyouni10/videogpt/pkg/ffmpeg"
+	"github.com/ahmadbasyouni10/videogpt/pkg/httpclient"
+	"github.com/ahmadbasyouni10/videogpt/pkg/qa"
+	"github.com/ahmadbasyouni10/videogpt/pkg/search"
 	"github.com/ahmadbasyouni10/videogpt/pkg/summarization"
 	"github.com/ahmadbasyouni10/videogpt/pkg/supabase"
 	"github.com/ahmadbasyouni10/videogpt/pkg/transcription"
+	"github.com/ahmadbasyouni10/videogpt/pkg/translation"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
@@ -24,15 +35,23 @@ type VideoHandler struct {
 	FFmpegProcessor      *ffmpeg.Processor
 	TranscriptionService transcription.Service
 	SummarizationService summarization.Service
+	TranslationService   translation.Service
+	ChaptersService      chapters.Service
+	QAService            qa.Service
+	SearchService        *search.Service // nil when search isn't configured
 }
 
 // NewVideoHandler creates a new video handler
-func NewVideoHandler(supabaseClient *supabase.Client, ffmpegProcessor *ffmpeg.Processor, transcriptionService transcription.Service, summarizationService summarization.Service) *VideoHandler {
+func NewVideoHandler(supabaseClient *supabase.Client, ffmpegProcessor *ffmpeg.Processor, transcriptionService transcription.Service, summarizationService summarization.Service, translationService translation.Service, chaptersService chapters.Service, qaService qa.Service, searchService *search.Service) *VideoHandler {
 	return &VideoHandler{
 		SupabaseClient:       supabaseClient,
 		FFmpegProcessor:      ffmpegProcessor,
 		TranscriptionService: transcriptionService,
 		SummarizationService: summarizationService,
+		TranslationService:   translationService,
+		ChaptersService:      chaptersService,
+		QAService:            qaService,
+		SearchService:        searchService,
 	}
 }
 
@@ -73,18 +92,32 @@ func (h *VideoHandler) UploadVideo(c echo.Context) error {
 	}
 
 	// Generate thumbnail
-	thumbnailPath, err := h.FFmpegProcessor.CreateThumbnail(tempFilePath)
-	if err != nil {
+	ctx := c.Request().Context()
+	// an ffmpeg build without a jpeg encoder can't make one, the upload goes ahead without it
+	thumbnailPath, err := h.FFmpegProcessor.CreateThumbnail(ctx, tempFilePath)
+	if errors.Is(err, ffmpeg.ErrMissingEncoder) {
+		fmt.Printf("Warning: skipping thumbnail: %v\n", err)
+		thumbnailPath = ""
+	} else if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create thumbnail"})
 	}
 
 	// Get video duration
-	duration, err := h.FFmpegProcessor.GetVideoDuration(tempFilePath)
+	duration, err := h.FFmpegProcessor.GetVideoDuration(ctx, tempFilePath)
 	if err != nil {
 		// Non-fatal error, continue without duration
 		fmt.Printf("Failed to get video duration: %v\n", err)
 	}
 
+	// Check for speech so we know up front whether transcription is worth it
+	var hasSpeech *bool
+	if speech, err := h.FFmpegProcessor.HasSpeech(ctx, tempFilePath); err != nil {
+		// Non-fatal error, leave it unknown
+		fmt.Printf("Failed to detect speech: %v\n", err)
+	} else {
+		hasSpeech = &speech
+	}
+
 	// Build path for storage
 	videoPath := fmt.Sprintf("%s%s", videoID, ext)
 	thumbnailStoragePath := fmt.Sprintf("%s.jpg", videoID)
@@ -99,31 +132,34 @@ func (h *VideoHandler) UploadVideo(c echo.Context) error {
 	fmt.Printf("DEBUG - Uploaded video URL: %s\n", videoURL)
 
 	// Upload thumbnail to Supabase
-	thumbnailFile, err := os.Open(thumbnailPath)
-	if err != nil {
-		fmt.Printf("Failed to open thumbnail file: %v\n", err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to process thumbnail"})
-	}
-	defer thumbnailFile.Close()
-
-	thumbnailFileInfo, err := thumbnailFile.Stat()
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get thumbnail file info"})
-	}
-
-	thumbnailHeader := &multipart.FileHeader{
-		Filename: filepath.Base(thumbnailPath),
-		Size:     thumbnailFileInfo.Size(),
-		Header:   make(map[string][]string),
-	}
-	thumbnailHeader.Header.Set("Content-Type", "image/jpeg")
-
-	thumbnailURL, err := h.SupabaseClient.UploadFile("thumbnails", thumbnailStoragePath, thumbnailFile, thumbnailHeader)
-	if err != nil {
-		fmt.Printf("Supabase thumbnail upload error: %v\n", err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to upload thumbnail"})
+	var thumbnailURL string
+	if thumbnailPath != "" {
+		thumbnailFile, err := os.Open(thumbnailPath)
+		if err != nil {
+			fmt.Printf("Failed to open thumbnail file: %v\n", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to process thumbnail"})
+		}
+		defer thumbnailFile.Close()
+
+		thumbnailFileInfo, err := thumbnailFile.Stat()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get thumbnail file info"})
+		}
+
+		thumbnailHeader := &multipart.FileHeader{
+			Filename: filepath.Base(thumbnailPath),
+			Size:     thumbnailFileInfo.Size(),
+			Header:   make(map[string][]string),
+		}
+		thumbnailHeader.Header.Set("Content-Type", "image/jpeg")
+
+		thumbnailURL, err = h.SupabaseClient.UploadFile("thumbnails", thumbnailStoragePath, thumbnailFile, thumbnailHeader)
+		if err != nil {
+			fmt.Printf("Supabase thumbnail upload error: %v\n", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to upload thumbnail"})
+		}
+		fmt.Printf("DEBUG - Uploaded thumbnail URL: %s\n", thumbnailURL)
 	}
-	fmt.Printf("DEBUG - Uploaded thumbnail URL: %s\n", thumbnailURL)
 
 	// Create video object
 	video := models.Video{
@@ -136,12 +172,15 @@ func (h *VideoHandler) UploadVideo(c echo.Context) error {
 		ThumbnailURL: thumbnailURL,
 		Duration:     duration,
 		VideoURL:     videoURL,
+		HasSpeech:    hasSpeech,
 	}
 
 	// Clean up temp files
 	defer func() {
 		os.Remove(tempFilePath)
-		os.Remove(thumbnailPath)
+		if thumbnailPath != "" {
+			os.Remove(thumbnailPath)
+		}
 	}()
 
 	// Here you would save the video to the database
@@ -179,79 +218,388 @@ func (h *VideoHandler) GetVideo(c echo.Context) error {
 	return c.Redirect(http.StatusTemporaryRedirect, videoURL)
 }
 
-// GenerateTranscript generates a transcript for a video
-func (h *VideoHandler) GenerateTranscript(c echo.Context) error {
-	videoID := c.Param("id")
-	if videoID == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Video ID is required"})
-	}
-
+// downloadVideo fetches the stored video into the temp directory for processing
+// the caller is responsible for removing the returned file
+func (h *VideoHandler) downloadVideo(ctx context.Context, videoID string) (string, error) {
 	// Get direct URL to the video in Supabase using the same pattern as in GetVideo
 	videoURL := fmt.Sprintf("%s/storage/v1/object/public/videos/%s.mp4",
 		h.SupabaseClient.URL, videoID)
 
 	// Download the video from the URL
-	resp, err := http.Get(videoURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", videoURL, nil)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error":   "Failed to download video",
-			"details": err.Error(),
-		})
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download video: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return c.JSON(http.StatusNotFound, map[string]string{
-			"error":   "Video not found",
-			"details": fmt.Sprintf("Status code: %d", resp.StatusCode),
-		})
+		return "", fmt.Errorf("%w: status code %d", errVideoNotFound, resp.StatusCode)
 	}
 
-	// Save to temp file for processing
-	tempFilePath := filepath.Join(h.FFmpegProcessor.TempDir, videoID+".mp4")
-	tempFile, err := os.Create(tempFilePath)
+	// Save to temp file for processing, uniquely named so concurrent requests for the same video don't share it
+	tempFile, err := os.CreateTemp(h.FFmpegProcessor.TempDir, videoID+"-*.mp4")
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to create temp file",
-		})
+		return "", fmt.Errorf("failed to create temp file: %w", err)
 	}
+	tempFilePath := tempFile.Name()
 
 	// Copy the response body to the temp file
 	_, err = io.Copy(tempFile, resp.Body)
 	tempFile.Close()
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to save video data",
+		os.Remove(tempFilePath)
+		return "", fmt.Errorf("failed to save video data: %w", err)
+	}
+
+	return tempFilePath, nil
+}
+
+// errVideoNotFound is returned by downloadVideo when storage has no such video
+var errVideoNotFound = errors.New("video not found")
+
+// downloadError turns a downloadVideo error into the matching response
+func downloadError(c echo.Context, err error) error {
+	if errors.Is(err, errVideoNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error":   "Video not found",
+			"details": err.Error(),
 		})
 	}
-	defer os.Remove(tempFilePath) // Clean up when done
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error":   "Failed to download video",
+		"details": err.Error(),
+	})
+}
 
-	// Extract audio using your existing FFmpeg processor
-	audioPath, err := h.FFmpegProcessor.ExtractAudio(tempFilePath)
+// providerError responds to a failed AI service call
+// rate limits become 429 with the provider's Retry-After, rejected input 422,
+// and 503 when every provider in the fallback chain is down so clients know to retry later
+func providerError(c echo.Context, message string, err error) error {
+	status := http.StatusInternalServerError
+	var apiErr *httpclient.Error
+	switch {
+	case errors.Is(err, httpclient.ErrRateLimited):
+		status = http.StatusTooManyRequests
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(apiErr.RetryAfter.Seconds()))))
+		}
+	case errors.Is(err, httpclient.ErrInvalidInput):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, fallback.ErrUnavailable):
+		status = http.StatusServiceUnavailable
+	}
+	return c.JSON(status, map[string]string{
+		"error":   message,
+		"details": err.Error(),
+	})
+}
+
+// ListAudioTracks lists the audio tracks in a video with their language tags
+func (h *VideoHandler) ListAudioTracks(c echo.Context) error {
+	videoID := c.Param("id")
+	if videoID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Video ID is required"})
+	}
+
+	ctx := c.Request().Context()
+	videoPath, err := h.downloadVideo(ctx, videoID)
+	if err != nil {
+		return downloadError(c, err)
+	}
+	defer os.Remove(videoPath)
+
+	tracks, err := h.FFmpegProcessor.ListAudioTracks(ctx, videoPath)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error":   "Failed to extract audio",
+			"error":   "Failed to list audio tracks",
 			"details": err.Error(),
 		})
 	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"video_id": videoID,
+		"tracks":   tracks,
+	})
+}
+
+// trackTranscript is the transcription result for one audio track
+type trackTranscript struct {
+	Track      ffmpeg.AudioTrack  `json:"track"`
+	Status     string             `json:"status"` // success or no_speech
+	Message    string             `json:"message,omitempty"`
+	Transcript *models.Transcript `json:"transcript,omitempty"`
+}
+
+// audioTask is what transcribeTrack does with the audio
+type audioTask int
+
+const (
+	taskTranscribe audioTask = iota // transcript in the spoken language
+	taskTranslate                   // English translation
+)
+
+// trackRequest is how a track should be transcribed
+type trackRequest struct {
+	Options transcription.Options
+	Task    audioTask
+	Diarize bool // label speakers, needs a transcription service that implements transcription.Diarizer
+}
+
+// transcribeTrack extracts one audio track and runs it through the transcription service
+// tracks with nothing to transcribe come back with status no_speech instead of an error
+func (h *VideoHandler) transcribeTrack(ctx context.Context, videoID string, videoPath string, track ffmpeg.AudioTrack, request trackRequest) (*trackTranscript, error) {
+	opts := request.Options
+
+	result := &trackTranscript{Track: track, Status: "no_speech"}
+
+	// Extract audio using your existing FFmpeg processor
+	audioPath, err := h.FFmpegProcessor.ExtractAudioTrack(ctx, videoPath, track.Index)
+	if errors.Is(err, ffmpeg.ErrNoAudio) {
+		result.Message = "Video has no audio track"
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract audio: %w", err)
+	}
 	defer os.Remove(audioPath) // Clean up audio file when done
 
-	// Use the transcription service to convert audio to text
-	transcript, err := h.TranscriptionService.TranscribeAudio(audioPath)
+	// Don't send pure silence to Whisper, it makes up text for it
+	if hasSpeech, err := h.FFmpegProcessor.HasSpeech(ctx, audioPath); err != nil {
+		fmt.Printf("Failed to detect speech, transcribing anyway: %v\n", err)
+	} else if !hasSpeech {
+		result.Message = "Audio track is silent"
+		return result, nil
+	}
+
+	// Split long recordings at pauses so each piece fits under Whisper's upload limit
+	audioChunks, err := h.FFmpegProcessor.SplitAudio(ctx, audioPath, transcription.MaxUploadBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split audio: %w", err)
+	}
+	defer ffmpeg.RemoveChunks(audioChunks)
+
+	chunks := make([]transcription.Chunk, len(audioChunks))
+	for i, audioChunk := range audioChunks {
+		chunks[i] = transcription.Chunk{
+			Path:     audioChunk.Path,
+			Offset:   audioChunk.Start,
+			Duration: audioChunk.Duration,
+		}
+	}
+
+	// Use the transcription service to convert audio to text, chunks run in parallel
+	var transcript *models.Transcript
+	if request.Task == taskTranslate {
+		transcript, err = transcription.TranslateChunks(ctx, h.TranscriptionService, chunks, opts, transcription.DefaultConcurrency)
+	} else {
+		transcript, err = transcription.TranscribeChunks(ctx, h.TranscriptionService, chunks, opts, transcription.DefaultConcurrency)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to transcribe audio: %w", err)
+	}
+
+	transcript.VideoID = videoID
+	if transcript.Language == "" && request.Task == taskTranscribe {
+		transcript.Language = track.Language
+	}
+
+	// Diarization runs over the whole track so speaker IDs stay consistent across chunks
+	if request.Diarize {
+		diarizer, ok := h.TranscriptionService.(transcription.Diarizer)
+		if !ok {
+			return nil, transcription.ErrDiarizationUnsupported
+		}
+		turns, err := diarizer.Diarize(ctx, audioPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to diarize audio: %w", err)
+		}
+		transcription.AssignSpeakers(transcript, turns)
+	}
+
+	result.Status = "success"
+	result.Transcript = transcript
+	return result, nil
+}
+
+// selectTracks picks the audio tracks a request asked for
+// "all_tracks=true" takes every track, "track" picks one by index and otherwise
+// "language" is used as a preference with the default track as the fallback
+func selectTracks(c echo.Context, tracks []ffmpeg.AudioTrack) ([]ffmpeg.AudioTrack, error) {
+	if c.FormValue("all_tracks") == "true" {
+		return tracks, nil
+	}
+
+	if trackParam := c.FormValue("track"); trackParam != "" {
+		index, err := strconv.Atoi(trackParam)
+		if err != nil || index < 0 || index >= len(tracks) {
+			return nil, fmt.Errorf("invalid track, video has %d audio tracks", len(tracks))
+		}
+		return []ffmpeg.AudioTrack{tracks[index]}, nil
+	}
+
+	track, _ := ffmpeg.SelectAudioTrack(tracks, c.FormValue("language"))
+	return []ffmpeg.AudioTrack{track}, nil
+}
+
+// transcriptionOptions reads the per-request transcription settings from the form
+// the base URL is deliberately not settable here, callers shouldn't be able to point us at arbitrary hosts
+func transcriptionOptions(c echo.Context) (transcription.Options, error) {
+	opts := transcription.Options{
+		Model:          c.FormValue("model"),
+		ResponseFormat: c.FormValue("response_format"),
+		Prompt:         c.FormValue("prompt"),
+	}
+
+	// Whisper only takes ISO-639-1 codes, longer tags are only used to pick the track
+	if language := c.FormValue("language"); len(language) == 2 {
+		opts.Language = strings.ToLower(language)
+	}
+
+	if value := c.FormValue("temperature"); value != "" {
+		temperature, err := strconv.ParseFloat(value, 64)
+		if err != nil || temperature < 0 || temperature > 1 {
+			return opts, fmt.Errorf("temperature must be a number between 0 and 1")
+		}
+		opts.Temperature = &temperature
+	}
+
+	switch opts.ResponseFormat {
+	case "", transcription.FormatVerboseJSON, transcription.FormatJSON, transcription.FormatText,
+		transcription.FormatSRT, transcription.FormatVTT:
+	default:
+		return opts, fmt.Errorf("unsupported response_format %q", opts.ResponseFormat)
+	}
+
+	return opts, nil
+}
+
+// GenerateTranscript generates a transcript for a video
+// form values pick the audio track: "track" is an index from ListAudioTracks,
+// "language" prefers a track tagged with that language, and "all_tracks=true"
+// transcribes every track separately
+// model, language, prompt, temperature and response_format override the Whisper defaults,
+// and "diarize=true" labels each segment with its speaker
+func (h *VideoHandler) GenerateTranscript(c echo.Context) error {
+	videoID := c.Param("id")
+	if videoID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Video ID is required"})
+	}
+
+	ctx := c.Request().Context()
+	tempFilePath, err := h.downloadVideo(ctx, videoID)
+	if err != nil {
+		return downloadError(c, err)
+	}
+	defer os.Remove(tempFilePath) // Clean up when done
+
+	tracks, err := h.FFmpegProcessor.ListAudioTracks(ctx, tempFilePath)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error":   "Failed to transcribe audio",
+			"error":   "Failed to list audio tracks",
 			"details": err.Error(),
 		})
 	}
+	if len(tracks) == 0 {
+		return noSpeechResponse(c, videoID, "Video has no audio track")
+	}
+
+	// Work out which tracks to transcribe
+	selected, err := selectTracks(c, tracks)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
 
-	// TODO: Save transcript to database if needed
+	opts, err := transcriptionOptions(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	// Steer Whisper towards the workspace's spellings, then fix whatever it still gets wrong
+	terms, err := h.workspaceGlossary(c)
+	if err != nil {
+		return glossaryError(c, err)
+	}
+	opts.Vocabulary = terms.Terms() // listed after the request or WHISPER_PROMPT prompt
+
+	request := trackRequest{
+		Options: opts,
+		Task:    taskTranscribe,
+		Diarize: c.FormValue("diarize") == "true",
+	}
+
+	results := make([]*trackTranscript, 0, len(selected))
+	for _, track := range selected {
+		result, err := h.transcribeTrack(ctx, videoID, tempFilePath, track, request)
+		if errors.Is(err, transcription.ErrDiarizationUnsupported) {
+			return c.JSON(http.StatusNotImplemented, map[string]string{"error": err.Error()})
+		}
+		if err != nil {
+			return providerError(c, "Failed to transcribe audio", err)
+		}
+		if result.Transcript != nil {
+			terms.Apply(result.Transcript)
+		}
+		results = append(results, result)
+	}
+
+	// Save each track's transcript, the first one with speech becomes the video's main transcript
+	savedMain := false
+	for _, result := range results {
+		if result.Transcript == nil {
+			continue
+		}
+		if err := h.saveTranscript(result.Transcript, fmt.Sprintf("a%d", result.Track.Index)); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error":   "Failed to save transcript",
+				"details": err.Error(),
+			})
+		}
+		if !savedMain {
+			if err := h.saveTranscript(result.Transcript, ""); err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error":   "Failed to save transcript",
+					"details": err.Error(),
+				})
+			}
+			h.indexTranscript(result.Transcript)
+			savedMain = true
+		}
+	}
+
+	if len(results) > 1 {
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"status":   "success",
+			"message":  "Audio tracks transcribed successfully",
+			"video_id": videoID,
+			"tracks":   results,
+		})
+	}
 
+	result := results[0]
+	if result.Status == "no_speech" {
+		return noSpeechResponse(c, videoID, result.Message)
+	}
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"status":     "success",
 		"message":    "Audio transcribed successfully",
 		"video_id":   videoID,
-		"transcript": transcript,
+		"track":      result.Track,
+		"transcript": result.Transcript,
+	})
+}
+
+// noSpeechResponse is the explicit result for videos with nothing to transcribe
+func noSpeechResponse(c echo.Context, videoID string, reason string) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":     "no_speech",
+		"message":    reason,
+		"video_id":   videoID,
+		"has_speech": false,
+		"transcript": nil,
 	})
 }
 
@@ -262,25 +610,63 @@ func (h *VideoHandler) GenerateSummary(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Video ID is required"})
 	}
 
-	// Get the transcript from the request
-	transcript := c.FormValue("transcript")
-	if transcript == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Transcript is required"})
+	// Get the transcript from the request, or fall back to the stored one
+	// (generated or imported from captions), which has the segments to chunk long recordings on
+	transcript := &models.Transcript{VideoID: videoID, Text: c.FormValue("transcript")}
+	if transcript.Text == "" {
+		stored, err := h.loadTranscript(videoID, "")
+		if errors.Is(err, supabase.ErrNotFound) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Transcript is required"})
+		}
+		if err != nil {
+			return transcriptError(c, err)
+		}
+		transcript = stored
 	}
 
-	// Now that we have the transcript, generate a summary
-	summary, err := h.SummarizationService.SummarizeText(transcript)
+	// Pick the prompt template and sampling settings (request, then workspace, then defaults)
+	opts, err := summaryOptions(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	workspace, err := workspaceID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	opts.Template, err = h.summaryTemplate(workspace, c.FormValue("template"))
+	if errors.Is(err, errUnknownTemplate) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error":   "Failed to generate summary",
+			"error":   "Failed to load summary templates",
 			"details": err.Error(),
 		})
 	}
 
+	// Now that we have the transcript, generate a summary
+	summary, err := h.SummarizationService.Summarize(c.Request().Context(), transcript, opts)
+	if err != nil {
+		return providerError(c, "Failed to generate summary", err)
+	}
+
+	// Keep it with the template version it was made with, the summary is still returned if this fails
+	if err := h.saveSummary(summary); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"status":   "success",
-		"message":  "Summary generated successfully",
-		"video_id": videoID,
-		"summary":  summary,
+		"status":           "success",
+		"message":          "Summary generated successfully",
+		"video_id":         videoID,
+		"summary":          summary.Text,
+		"structured":       summary.Structured,
+		"style":            summary.Style,
+		"length":           summary.Length,
+		"template":         summary.Template,
+		"template_version": summary.TemplateVersion,
+		"model":            summary.Model,
+		"temperature":      summary.Temperature,
+		"chunks":           summary.Chunks,
 	})
 }
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Chapter is a titled stretch of a video, times in seconds from the start
+type Chapter struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Title string  `json:"title"`
+}
+
+// Chapters is the chapter list generated for a video
+type Chapters struct {
+	VideoID   string    `json:"video_id"`
+	Chapters  []Chapter `json:"chapters"`
+	Embedded  bool      `json:"embedded"` // written into the stored MP4 as metadata chapters
+	CreatedAt time.Time `json:"created_at"`
+
+	// SourceCreatedAt is the CreatedAt of the transcript the chapters were made from,
+	// stored chapters are stale once that transcript is regenerated
+	SourceCreatedAt *time.Time `json:"source_created_at,omitempty"`
+}
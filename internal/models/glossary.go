@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// GlossaryEntry is a term a workspace wants transcribed consistently
+// Aliases are the misspellings to correct, PreferredSpelling is how the term should be written
+// (the Term itself when empty)
+type GlossaryEntry struct {
+	ID                string    `json:"id"`
+	Term              string    `json:"term"`
+	Aliases           []string  `json:"aliases,omitempty"`
+	PreferredSpelling string    `json:"preferred_spelling,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// Spelling returns the form the term should appear in
+func (e GlossaryEntry) Spelling() string {
+	if e.PreferredSpelling != "" {
+		return e.PreferredSpelling
+	}
+	return e.Term
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+// whisperLanguages maps the language names Whisper reports (verbose_json says "english", not "en")
+// to their ISO 639-1 codes, Whisper's own codes where there is none
+var whisperLanguages = map[string]string{
+	"afrikaans": "af", "albanian": "sq", "amharic": "am", "arabic": "ar", "armenian": "hy",
+	"assamese": "as", "azerbaijani": "az", "bashkir": "ba", "basque": "eu", "belarusian": "be",
+	"bengali": "bn", "bosnian": "bs", "breton": "br", "bulgarian": "bg", "cantonese": "yue",
+	"catalan": "ca", "chinese": "zh", "croatian": "hr", "czech": "cs", "danish": "da",
+	"dutch": "nl", "english": "en", "estonian": "et", "faroese": "fo", "finnish": "fi",
+	"french": "fr", "galician": "gl", "georgian": "ka", "german": "de", "greek": "el",
+	"gujarati": "gu", "haitian creole": "ht", "hausa": "ha", "hawaiian": "haw", "hebrew": "he",
+	"hindi": "hi", "hungarian": "hu", "icelandic": "is", "indonesian": "id", "italian": "it",
+	"japanese": "ja", "javanese": "jw", "kannada": "kn", "kazakh": "kk", "khmer": "km",
+	"korean": "ko", "lao": "lo", "latin": "la", "latvian": "lv", "lingala": "ln",
+	"lithuanian": "lt", "luxembourgish": "lb", "macedonian": "mk", "malagasy": "mg", "malay": "ms",
+	"malayalam": "ml", "maltese": "mt", "maori": "mi", "marathi": "mr", "mongolian": "mn",
+	"myanmar": "my", "nepali": "ne", "norwegian": "no", "nynorsk": "nn", "occitan": "oc",
+	"pashto": "ps", "persian": "fa", "polish": "pl", "portuguese": "pt", "punjabi": "pa",
+	"romanian": "ro", "russian": "ru", "sanskrit": "sa", "serbian": "sr", "shona": "sn",
+	"sindhi": "sd", "sinhala": "si", "slovak": "sk", "slovenian": "sl", "somali": "so",
+	"spanish": "es", "sundanese": "su", "swahili": "sw", "swedish": "sv", "tagalog": "tl",
+	"tajik": "tg", "tamil": "ta", "tatar": "tt", "telugu": "te", "thai": "th",
+	"tibetan": "bo", "turkish": "tr", "turkmen": "tk", "ukrainian": "uk", "urdu": "ur",
+	"uzbek": "uz", "vietnamese": "vi", "welsh": "cy", "yiddish": "yi", "yoruba": "yo",
+}
+
+// languageTagPattern matches BCP-47 style tags like "en", "haw" or "pt-BR"
+var languageTagPattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// LanguageCode turns a transcript language into a language code
+// Whisper's names ("english") are mapped to their code, codes pass through lowercased,
+// and anything else is unknown and comes back empty
+func LanguageCode(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if code, ok := whisperLanguages[language]; ok {
+		return code
+	}
+	if languageTagPattern.MatchString(language) {
+		return language
+	}
+	return ""
+}
@@ -0,0 +1,14 @@
+// Package modelstest has fixtures for tests that work on transcripts
+package modelstest
+
+import "github.com/ahmadbasyouni10/videogpt/internal/models"
+
+// Segments makes one timed segment per text, back to back and each seconds long
+func Segments(seconds float64, texts ...string) []models.Segment {
+	segments := make([]models.Segment, len(texts))
+	for i, text := range texts {
+		start := float64(i) * seconds
+		segments[i] = models.Segment{ID: i, Start: start, End: start + seconds, Text: text}
+	}
+	return segments
+}
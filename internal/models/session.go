@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Session is a conversation about one video, kept so follow-up questions have context
+type Session struct {
+	ID        string    `json:"id"`
+	VideoID   string    `json:"video_id"`
+	Turns     []Turn    `json:"turns"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Turn is one message in a session, a question from the user or an answer from the assistant
+type Turn struct {
+	Role      string     `json:"role"` // "user" or "assistant"
+	Content   string     `json:"content"`
+	Citations []Citation `json:"citations,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Citation points an answer's [n] marker at the part of the transcript it came from
+// Start and End are zero for transcripts without timings
+type Citation struct {
+	Number int     `json:"number"`
+	Start  float64 `json:"start"`
+	End    float64 `json:"end"`
+	Text   string  `json:"text"`
+	Link   string  `json:"link,omitempty"` // the video at Start
+}
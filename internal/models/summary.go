@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Summary is a generated summary of a video's transcript
+// the template and sampling settings are kept so summaries can be compared after prompts change
+type Summary struct {
+	VideoID         string             `json:"video_id,omitempty"`
+	Text            string             `json:"text"`                 // the abstract for structured summaries
+	Structured      *StructuredSummary `json:"structured,omitempty"` // only for the structured style
+	Style           string             `json:"style,omitempty"`      // empty when the template's own shape was used
+	Length          string             `json:"length,omitempty"`
+	Template        string             `json:"template"`
+	TemplateVersion string             `json:"template_version"`
+	Model           string             `json:"model"`
+	Temperature     float64            `json:"temperature"`
+	TopP            *float64           `json:"top_p,omitempty"`
+	Chunks          int                `json:"chunks"` // transcript chunks summarized, 1 when it fit in one prompt
+	CreatedAt       time.Time          `json:"created_at"`
+}
+
+// StructuredSummary is a summary broken into fields for other tools to consume
+// every field is always present, lists are empty rather than missing
+type StructuredSummary struct {
+	Title       string       `json:"title"`
+	Abstract    string       `json:"abstract"`
+	KeyPoints   []string     `json:"key_points"`
+	Topics      []string     `json:"topics"`
+	ActionItems []ActionItem `json:"action_items"`
+	Decisions   []string     `json:"decisions"`
+	Questions   []string     `json:"questions"` // left open in the recording
+	Quotes      []Quote      `json:"quotes"`
+}
+
+// ActionItem is a task someone took on, Owner and Due are empty when nobody said
+type ActionItem struct {
+	Task  string `json:"task"`
+	Owner string `json:"owner"`
+	Due   string `json:"due"`
+}
+
+// Quote is a notable line from the recording, Speaker is empty when unknown
+type Quote struct {
+	Text    string `json:"text"`
+	Speaker string `json:"speaker"`
+}
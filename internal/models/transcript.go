@@ -0,0 +1,118 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Transcript is a timed transcript of a video's audio
+// times are in seconds from the start of the video
+type Transcript struct {
+	VideoID        string            `json:"video_id,omitempty"`
+	Language       string            `json:"language,omitempty"`
+	SourceLanguage string            `json:"source_language,omitempty"` // language of the audio when this is a translation
+	Duration       float64           `json:"duration,omitempty"`
+	Text           string            `json:"text"`
+	Segments       []Segment         `json:"segments"`
+	Words          []Word            `json:"words,omitempty"`
+	Source         string            `json:"source,omitempty"`   // whisper, imported, ...
+	Speakers       map[string]string `json:"speakers,omitempty"` // diarization speaker IDs (e.g. "SPEAKER_00") to display names
+	CreatedAt      time.Time         `json:"created_at"`
+
+	// SourceCreatedAt is the CreatedAt of the transcript this one was translated from,
+	// a cached translation is stale once the transcript it came from is regenerated
+	SourceCreatedAt *time.Time `json:"source_created_at,omitempty"`
+}
+
+// Segment is a phrase or sentence of the transcript with its timing
+// AvgLogprob and NoSpeechProb come from Whisper and are zero for other sources
+type Segment struct {
+	ID           int     `json:"id"`
+	Start        float64 `json:"start"`
+	End          float64 `json:"end"`
+	Text         string  `json:"text"`
+	AvgLogprob   float64 `json:"avg_logprob,omitempty"`
+	NoSpeechProb float64 `json:"no_speech_prob,omitempty"`
+	Speaker      string  `json:"speaker,omitempty"` // speaker ID from diarization
+}
+
+// Word is a single word with its timing
+type Word struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Word  string  `json:"word"`
+}
+
+// SpeakerName returns the display name for a speaker ID, or the ID when it hasn't been renamed
+func (t *Transcript) SpeakerName(speaker string) string {
+	if name, ok := t.Speakers[speaker]; ok && name != "" {
+		return name
+	}
+	return speaker
+}
+
+// Append adds another transcript's segments and words after this one
+// offset is where the other transcript starts on this one's timeline,
+// which is how chunked transcriptions are stitched back together
+func (t *Transcript) Append(other *Transcript, offset float64) {
+	for _, segment := range other.Segments {
+		segment.ID = len(t.Segments)
+		segment.Start += offset
+		segment.End += offset
+		t.Segments = append(t.Segments, segment)
+	}
+	for _, word := range other.Words {
+		word.Start += offset
+		word.End += offset
+		t.Words = append(t.Words, word)
+	}
+
+	if text := strings.TrimSpace(other.Text); text != "" {
+		if t.Text != "" {
+			t.Text += " "
+		}
+		t.Text += text
+	}
+	if end := offset + other.Duration; end > t.Duration {
+		t.Duration = end
+	}
+	if t.Language == "" {
+		t.Language = other.Language
+	}
+}
+
+// sentencePattern splits untimed text into sentences
+var sentencePattern = regexp.MustCompile(`[^.!?]+[.!?]*\s*`)
+
+// TextSegments returns the segments that have text
+// a transcript that is only text (pasted in, or a text-format response) is split into
+// untimed sentences instead, so it can still be worked through on sensible boundaries
+func (t *Transcript) TextSegments() []Segment {
+	var segments []Segment
+	for _, segment := range t.Segments {
+		if strings.TrimSpace(segment.Text) != "" {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) > 0 {
+		return segments
+	}
+
+	for _, sentence := range sentencePattern.FindAllString(t.Text, -1) {
+		if sentence = strings.TrimSpace(sentence); sentence != "" {
+			segments = append(segments, Segment{ID: len(segments), Text: sentence})
+		}
+	}
+	return segments
+}
+
+// FormatTime formats seconds as H:MM:SS or M:SS, the way times are shown in prompts
+func FormatTime(seconds float64) string {
+	total := int(seconds)
+	if total >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", total/3600, total/60%60, total%60)
+	}
+	return fmt.Sprintf("%d:%02d", total/60, total%60)
+}
@@ -0,0 +1,275 @@
+// Package captions turns timed transcripts into subtitle files and back
+package captions
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+)
+
+// Options controls how transcript segments are broken into caption cues
+// the defaults follow common broadcast guidelines (42 chars, 2 lines, 17 chars/sec)
+type Options struct {
+	MaxCharsPerLine int     // longest line before wrapping
+	MaxLines        int     // lines per cue
+	MinDuration     float64 // shortest time a cue stays on screen, in seconds
+	MaxDuration     float64 // longest time a cue stays on screen, in seconds
+	MaxCPS          float64 // reading speed limit in characters per second
+}
+
+// DefaultOptions returns the caption settings used when a request doesn't override them
+func DefaultOptions() Options {
+	return Options{
+		MaxCharsPerLine: 42,
+		MaxLines:        2,
+		MinDuration:     1.0,
+		MaxDuration:     7.0,
+		MaxCPS:          17,
+	}
+}
+
+// withDefaults fills in anything left at zero
+func (o Options) withDefaults() Options {
+	defaults := DefaultOptions()
+	if o.MaxCharsPerLine <= 0 {
+		o.MaxCharsPerLine = defaults.MaxCharsPerLine
+	}
+	if o.MaxLines <= 0 {
+		o.MaxLines = defaults.MaxLines
+	}
+	if o.MinDuration <= 0 {
+		o.MinDuration = defaults.MinDuration
+	}
+	if o.MaxDuration <= 0 {
+		o.MaxDuration = defaults.MaxDuration
+	}
+	if o.MaxDuration < o.MinDuration {
+		o.MaxDuration = o.MinDuration
+	}
+	if o.MaxCPS <= 0 {
+		o.MaxCPS = defaults.MaxCPS
+	}
+	return o
+}
+
+// Cue is one caption on screen
+type Cue struct {
+	Start   float64  `json:"start"`
+	End     float64  `json:"end"`
+	Lines   []string `json:"lines"`
+	Speaker string   `json:"speaker,omitempty"` // display name, empty when the transcript isn't diarized
+}
+
+// Text returns the cue's lines joined with spaces
+func (c Cue) Text() string {
+	return strings.Join(c.Lines, " ")
+}
+
+// timedWord is a word with its timing, either from the transcript or estimated
+type timedWord struct {
+	text  string
+	start float64
+	end   float64
+}
+
+// BuildCues breaks the transcript segments into caption cues that respect the options
+func BuildCues(transcript *models.Transcript, opts Options) []Cue {
+	opts = opts.withDefaults()
+
+	var cues []Cue
+	for _, segment := range transcript.Segments {
+		words := segmentWords(transcript, segment)
+		speaker := transcript.SpeakerName(segment.Speaker)
+
+		// greedily fill cues with words until the lines or the time run out
+		var current []timedWord
+		flush := func() {
+			if len(current) == 0 {
+				return
+			}
+			cues = append(cues, Cue{
+				Start:   current[0].start,
+				End:     current[len(current)-1].end,
+				Lines:   wrapLines(current, opts.MaxCharsPerLine),
+				Speaker: speaker,
+			})
+			current = nil
+		}
+
+		for _, word := range words {
+			if len(current) > 0 {
+				tooLong := !fits(append(current[:len(current):len(current)], word), opts)
+				tooSlow := word.end-current[0].start > opts.MaxDuration
+				if tooLong || tooSlow {
+					flush()
+				}
+			}
+			current = append(current, word)
+		}
+		flush()
+	}
+
+	return fixTiming(cues, opts)
+}
+
+// segmentWords returns the words of a segment with their timings
+// Whisper word timestamps are used when present, otherwise the segment's time is
+// spread over its words in proportion to their length
+func segmentWords(transcript *models.Transcript, segment models.Segment) []timedWord {
+	var words []timedWord
+	for _, word := range transcript.Words {
+		if word.Start >= segment.Start && word.End <= segment.End+0.01 {
+			if text := strings.TrimSpace(word.Word); text != "" {
+				words = append(words, timedWord{text: text, start: word.Start, end: word.End})
+			}
+		}
+	}
+	// only trust word timings when they cover the whole segment text
+	if len(words) > 0 && len(words) == len(strings.Fields(segment.Text)) {
+		return words
+	}
+
+	fields := strings.Fields(segment.Text)
+	total := 0
+	for _, field := range fields {
+		total += utf8.RuneCountInString(field) + 1
+	}
+
+	words = words[:0]
+	position := 0
+	duration := segment.End - segment.Start
+	for _, field := range fields {
+		length := utf8.RuneCountInString(field) + 1
+		words = append(words, timedWord{
+			text:  field,
+			start: segment.Start + duration*float64(position)/float64(total),
+			end:   segment.Start + duration*float64(position+length)/float64(total),
+		})
+		position += length
+	}
+	return words
+}
+
+// wrapGreedy fills each line with as many words as fit in width
+func wrapGreedy(texts []string, width int) []string {
+	var lines []string
+	var line []string
+	lineLength := 0
+	for _, text := range texts {
+		length := utf8.RuneCountInString(text)
+		if len(line) > 0 && lineLength+1+length > width {
+			lines = append(lines, strings.Join(line, " "))
+			line = nil
+			lineLength = 0
+		}
+		if len(line) > 0 {
+			lineLength++
+		}
+		line = append(line, text)
+		lineLength += length
+	}
+	if len(line) > 0 {
+		lines = append(lines, strings.Join(line, " "))
+	}
+	return lines
+}
+
+// wrapLines splits the words into lines of at most maxChars
+func wrapLines(words []timedWord, maxChars int) []string {
+	texts := make([]string, len(words))
+	for i, word := range words {
+		texts[i] = word.text
+	}
+	return balanceLines(texts, maxChars)
+}
+
+// balanceLines wraps the texts into lines of at most maxChars
+// after finding the fewest lines that work we narrow the width as long as the line
+// count stays the same, which balances the lines instead of leaving a short orphan
+func balanceLines(texts []string, maxChars int) []string {
+	lines := wrapGreedy(texts, maxChars)
+	for width := maxChars - 1; width > 0; width-- {
+		narrower := wrapGreedy(texts, width)
+		if len(narrower) != len(lines) {
+			break
+		}
+		lines = narrower
+	}
+	return lines
+}
+
+// fits reports whether the words can be wrapped into the allowed number of lines
+func fits(words []timedWord, opts Options) bool {
+	texts := make([]string, len(words))
+	for i, word := range words {
+		texts[i] = word.text
+	}
+	return len(wrapGreedy(texts, opts.MaxCharsPerLine)) <= opts.MaxLines
+}
+
+// fixTiming stretches cues that are too short to read, without overlapping the next cue
+// when even the time up to the next cue is too short for MaxCPS, words are moved from the
+// end of the cue to the start of the next one, which can be stretched into a pause later on
+func fixTiming(cues []Cue, opts Options) []Cue {
+	for i := range cues {
+		if i+1 < len(cues) {
+			reflow(&cues[i], &cues[i+1], opts)
+		}
+
+		chars := utf8.RuneCountInString(cues[i].Text())
+
+		// long enough for the minimum and for the reading speed limit
+		want := opts.MinDuration
+		if readingTime := float64(chars) / opts.MaxCPS; readingTime > want {
+			want = readingTime
+		}
+		if want > opts.MaxDuration {
+			want = opts.MaxDuration
+		}
+
+		end := cues[i].Start + want
+		if end < cues[i].End {
+			end = cues[i].End
+		}
+		if i+1 < len(cues) && end > cues[i+1].Start {
+			end = cues[i+1].Start
+		}
+		if end > cues[i].End {
+			cues[i].End = end
+		}
+	}
+	return cues
+}
+
+// reflow moves words from the end of cue to the start of next until cue can be read
+// at MaxCPS in the time before next starts
+// it stops at one word, and won't mix speakers or make next break the line limits,
+// so a cue that still reads too fast is left for the viewer to skim
+func reflow(cue *Cue, next *Cue, opts Options) {
+	if cue.Speaker != next.Speaker {
+		return
+	}
+	available := next.Start - cue.Start
+
+	words := strings.Fields(cue.Text())
+	nextWords := strings.Fields(next.Text())
+	moved := 0
+	for len(words)-moved > 1 {
+		chars := utf8.RuneCountInString(strings.Join(words[:len(words)-moved], " "))
+		if float64(chars)/opts.MaxCPS <= available {
+			break
+		}
+		candidate := append(append([]string{}, words[len(words)-moved-1:]...), nextWords...)
+		if len(wrapGreedy(candidate, opts.MaxCharsPerLine)) > opts.MaxLines {
+			break
+		}
+		moved++
+	}
+	if moved == 0 {
+		return
+	}
+
+	cue.Lines = balanceLines(words[:len(words)-moved], opts.MaxCharsPerLine)
+	next.Lines = balanceLines(append(append([]string{}, words[len(words)-moved:]...), nextWords...), opts.MaxCharsPerLine)
+}
@@ -0,0 +1,135 @@
+package captions
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+)
+
+// SourceImported marks transcripts that came from an uploaded caption file instead of a transcription service
+const SourceImported = "imported"
+
+// timingPattern matches a cue timing line, in SRT ("00:00:01,000 --> 00:00:03,500")
+// or WebVTT form ("00:01.000 --> 00:03.500 align:start"), hours are optional in WebVTT
+var timingPattern = regexp.MustCompile(`^\s*((?:\d+:)?\d{1,2}:\d{2}[.,]\d{1,3})\s*-->\s*((?:\d+:)?\d{1,2}:\d{2}[.,]\d{1,3})`)
+
+// tagPattern matches WebVTT/SRT markup like <v Speaker>, <i>, </c> and <00:00:01.000>
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// Parse reads an SRT or WebVTT file into a transcript
+// each cue becomes a segment, the format is detected from the WEBVTT header when it is empty
+func Parse(format Format, data []byte) (*models.Transcript, error) {
+	// strip a UTF-8 byte order mark, editors love adding them to caption files
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	if format == "" {
+		format = FormatSRT
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("WEBVTT")) {
+			format = FormatVTT
+		}
+	}
+	if format != FormatSRT && format != FormatVTT {
+		return nil, fmt.Errorf("cannot import %s captions, only srt and vtt are supported", format)
+	}
+
+	transcript := &models.Transcript{
+		Segments:  []models.Segment{},
+		Source:    SourceImported,
+		CreatedAt: time.Now(),
+	}
+
+	var texts []string
+	var segment *models.Segment
+	var lines []string
+	flush := func() {
+		if segment != nil {
+			segment.Text = strings.TrimSpace(strings.Join(lines, " "))
+			if segment.Text != "" {
+				segment.ID = len(transcript.Segments)
+				transcript.Segments = append(transcript.Segments, *segment)
+				texts = append(texts, segment.Text)
+			}
+		}
+		segment = nil
+		lines = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		// a blank line ends the cue
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+
+		if match := timingPattern.FindStringSubmatch(line); match != nil {
+			flush()
+			start, err := parseTimestamp(match[1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			end, err := parseTimestamp(match[2])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			segment = &models.Segment{Start: start, End: end}
+			continue
+		}
+
+		// anything outside a cue is a header, an SRT counter, a VTT cue id or a NOTE/STYLE block
+		if segment == nil {
+			continue
+		}
+
+		text := html.UnescapeString(tagPattern.ReplaceAllString(line, ""))
+		if text = strings.TrimSpace(text); text != "" {
+			lines = append(lines, text)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read captions: %w", err)
+	}
+	flush()
+
+	if len(transcript.Segments) == 0 {
+		return nil, fmt.Errorf("no cues found in %s captions", format)
+	}
+
+	transcript.Text = strings.Join(texts, " ")
+	transcript.Duration = transcript.Segments[len(transcript.Segments)-1].End
+	return transcript, nil
+}
+
+// parseTimestamp parses "HH:MM:SS,mmm", "HH:MM:SS.mmm" or "MM:SS.mmm" into seconds
+func parseTimestamp(value string) (float64, error) {
+	value = strings.Replace(value, ",", ".", 1)
+	parts := strings.Split(value, ":")
+
+	var hours, minutes int
+	var seconds float64
+	var err error
+	switch len(parts) {
+	case 3:
+		_, err = fmt.Sscanf(value, "%d:%d:%f", &hours, &minutes, &seconds)
+	case 2:
+		_, err = fmt.Sscanf(value, "%d:%f", &minutes, &seconds)
+	default:
+		err = fmt.Errorf("unexpected number of fields")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q: %w", value, err)
+	}
+
+	return float64(hours*3600+minutes*60) + seconds, nil
+}
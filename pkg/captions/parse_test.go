@@ -0,0 +1,109 @@
+package captions
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		data   string
+		want   []models.Segment
+	}{
+		{
+			name:   "srt",
+			format: FormatSRT,
+			data: "\xef\xbb\xbf1\r\n00:00:01,000 --> 00:00:03,500\r\nHello\r\nthere.\r\n\r\n" +
+				"2\r\n00:00:04,000 --> 00:00:05,250\r\n<i>Fish &amp; chips</i>\r\n",
+			want: []models.Segment{
+				{ID: 0, Start: 1, End: 3.5, Text: "Hello there."},
+				{ID: 1, Start: 4, End: 5.25, Text: "Fish & chips"},
+			},
+		},
+		{
+			name: "vtt detected from the header",
+			data: "WEBVTT\n\nNOTE written by hand\n\n" +
+				"intro\n00:01.000 --> 00:02.000 align:start\n<v Alice>Hi</v>\n\n" +
+				"01:00:00.000 --> 01:00:01.500\nBye\n",
+			want: []models.Segment{
+				{ID: 0, Start: 1, End: 2, Text: "Hi"},
+				{ID: 1, Start: 3600, End: 3601.5, Text: "Bye"},
+			},
+		},
+		{
+			name:   "cues without text are dropped",
+			format: FormatSRT,
+			data:   "1\n00:00:01,000 --> 00:00:02,000\n<i></i>\n\n2\n00:00:02,000 --> 00:00:03,000\nkept\n",
+			want: []models.Segment{
+				{ID: 0, Start: 2, End: 3, Text: "kept"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transcript, err := Parse(tt.format, []byte(tt.data))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !reflect.DeepEqual(transcript.Segments, tt.want) {
+				t.Errorf("segments = %+v, want %+v", transcript.Segments, tt.want)
+			}
+			if transcript.Source != SourceImported {
+				t.Errorf("source = %q, want %q", transcript.Source, SourceImported)
+			}
+			if last := tt.want[len(tt.want)-1]; transcript.Duration != last.End {
+				t.Errorf("duration = %v, want %v", transcript.Duration, last.End)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		data   string
+	}{
+		{name: "unsupported format", format: FormatTTML, data: "<tt/>"},
+		{name: "no cues", format: FormatSRT, data: "just some text\n"},
+		{name: "malformed timing line", format: FormatVTT, data: "WEBVTT\n\n00:00.000 --> 00:99:1.000\nHi\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.format, []byte(tt.data)); err == nil {
+				t.Error("Parse succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	transcript := &models.Transcript{
+		Segments: []models.Segment{
+			{Start: 0.5, End: 2, Text: "First line."},
+			{Start: 2.25, End: 4.75, Text: "Second line."},
+		},
+	}
+
+	for _, format := range []Format{FormatSRT, FormatVTT} {
+		t.Run(string(format), func(t *testing.T) {
+			data, err := Render(format, transcript, DefaultOptions())
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			parsed, err := Parse(format, data)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if len(parsed.Segments) != 2 || parsed.Segments[1].Text != "Second line." || parsed.Segments[1].End != 4.75 {
+				t.Errorf("round trip gave %+v", parsed.Segments)
+			}
+		})
+	}
+}
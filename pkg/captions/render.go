@@ -0,0 +1,200 @@
+package captions
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+)
+
+// Format is a caption file format
+type Format string
+
+const (
+	FormatSRT  Format = "srt"
+	FormatVTT  Format = "vtt"
+	FormatTTML Format = "ttml"
+	FormatSBV  Format = "sbv"
+	FormatTXT  Format = "txt"
+	FormatJSON Format = "json"
+)
+
+// ParseFormat checks that the format is one we can render
+func ParseFormat(format string) (Format, error) {
+	switch f := Format(strings.ToLower(format)); f {
+	case FormatSRT, FormatVTT, FormatTTML, FormatSBV, FormatTXT, FormatJSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("unsupported caption format %q", format)
+}
+
+// ContentType returns the MIME type to serve the format with
+func (f Format) ContentType() string {
+	switch f {
+	case FormatSRT:
+		return "application/x-subrip; charset=utf-8"
+	case FormatVTT:
+		return "text/vtt; charset=utf-8"
+	case FormatTTML:
+		return "application/ttml+xml; charset=utf-8"
+	case FormatJSON:
+		return "application/json; charset=utf-8"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// Render renders the transcript as a caption file
+func Render(format Format, transcript *models.Transcript, opts Options) ([]byte, error) {
+	// plain text is the transcript itself, one segment per line, no cue splitting
+	if format == FormatTXT {
+		var buf bytes.Buffer
+		for _, segment := range transcript.Segments {
+			if speaker := transcript.SpeakerName(segment.Speaker); speaker != "" {
+				buf.WriteString(speaker + ": ")
+			}
+			buf.WriteString(strings.TrimSpace(segment.Text))
+			buf.WriteString("\n")
+		}
+		return buf.Bytes(), nil
+	}
+
+	cues := BuildCues(transcript, opts)
+
+	switch format {
+	case FormatSRT:
+		return renderSRT(cues), nil
+	case FormatVTT:
+		return renderVTT(cues), nil
+	case FormatTTML:
+		return renderTTML(cues, transcript.Language)
+	case FormatSBV:
+		return renderSBV(cues), nil
+	case FormatJSON:
+		return json.MarshalIndent(map[string]interface{}{
+			"language": transcript.Language,
+			"cues":     cues,
+		}, "", "  ")
+	}
+	return nil, fmt.Errorf("unsupported caption format %q", format)
+}
+
+// splitTime breaks seconds into h, m, s, ms, rounding to the millisecond
+func splitTime(seconds float64) (int, int, int, int) {
+	if seconds < 0 {
+		seconds = 0
+	}
+	total := int(seconds*1000 + 0.5)
+	return total / 3600000, total / 60000 % 60, total / 1000 % 60, total % 1000
+}
+
+// formatTimestamp formats seconds as HH:MM:SS<sep>mmm
+func formatTimestamp(seconds float64, sep string) string {
+	h, m, s, ms := splitTime(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", h, m, s, sep, ms)
+}
+
+// labeledLines returns each cue's lines with "Name: " in front of the first line
+// whenever the speaker changes, for formats that have no speaker markup of their own
+func labeledLines(cues []Cue) [][]string {
+	labeled := make([][]string, len(cues))
+	previous := ""
+	for i, cue := range cues {
+		lines := append([]string(nil), cue.Lines...)
+		if cue.Speaker != "" && cue.Speaker != previous && len(lines) > 0 {
+			lines[0] = cue.Speaker + ": " + lines[0]
+		}
+		previous = cue.Speaker
+		labeled[i] = lines
+	}
+	return labeled
+}
+
+func renderSRT(cues []Cue) []byte {
+	labeled := labeledLines(cues)
+
+	var buf bytes.Buffer
+	for i, cue := range cues {
+		fmt.Fprintf(&buf, "%d\n%s --> %s\n%s\n\n",
+			i+1,
+			formatTimestamp(cue.Start, ","),
+			formatTimestamp(cue.End, ","),
+			strings.Join(labeled[i], "\n"),
+		)
+	}
+	return buf.Bytes()
+}
+
+func renderVTT(cues []Cue) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("WEBVTT\n\n")
+	for _, cue := range cues {
+		text := escapeVTT(strings.Join(cue.Lines, "\n"))
+		// WebVTT has a voice tag, players can style or show the speaker from it
+		if cue.Speaker != "" {
+			text = fmt.Sprintf("<v %s>%s", escapeVTT(cue.Speaker), text)
+		}
+		fmt.Fprintf(&buf, "%s --> %s\n%s\n\n",
+			formatTimestamp(cue.Start, "."),
+			formatTimestamp(cue.End, "."),
+			text,
+		)
+	}
+	return buf.Bytes()
+}
+
+// escapeVTT escapes the characters WebVTT treats as markup
+func escapeVTT(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// renderSBV renders YouTube's SubViewer format, which uses H:MM:SS.mmm with no hour padding
+func renderSBV(cues []Cue) []byte {
+	labeled := labeledLines(cues)
+
+	var buf bytes.Buffer
+	for i, cue := range cues {
+		fmt.Fprintf(&buf, "%s,%s\n%s\n\n",
+			sbvTimestamp(cue.Start),
+			sbvTimestamp(cue.End),
+			strings.Join(labeled[i], "\n"),
+		)
+	}
+	return buf.Bytes()
+}
+
+func sbvTimestamp(seconds float64) string {
+	h, m, s, ms := splitTime(seconds)
+	return fmt.Sprintf("%d:%02d:%02d.%03d", h, m, s, ms)
+}
+
+// renderTTML renders a minimal TTML document with one <p> per cue
+// xml:lang has to be a language tag, Whisper's "english" becomes "en" and an unknown language is left empty
+func renderTTML(cues []Cue, language string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	fmt.Fprintf(&buf, "<tt xmlns=\"http://www.w3.org/ns/ttml\" xml:lang=\"%s\">\n", escapeXML(models.LanguageCode(language)))
+	buf.WriteString("  <body>\n    <div>\n")
+	labeled := labeledLines(cues)
+	for n, cue := range cues {
+		lines := make([]string, len(labeled[n]))
+		for i, line := range labeled[n] {
+			lines[i] = escapeXML(line)
+		}
+		fmt.Fprintf(&buf, "      <p begin=\"%s\" end=\"%s\">%s</p>\n",
+			formatTimestamp(cue.Start, "."),
+			formatTimestamp(cue.End, "."),
+			strings.Join(lines, "<br/>"),
+		)
+	}
+	buf.WriteString("    </div>\n  </body>\n</tt>\n")
+	return buf.Bytes(), nil
+}
+
+func escapeXML(text string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(text))
+	return buf.String()
+}
@@ -0,0 +1,227 @@
+package captions
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+)
+
+func TestRender(t *testing.T) {
+	transcript := &models.Transcript{
+		Language: "english",
+		Segments: []models.Segment{
+			{Start: 0, End: 2, Text: "Hello there.", Speaker: "SPEAKER_00"},
+			{Start: 2.5, End: 4, Text: "Fish & chips <now>", Speaker: "SPEAKER_01"},
+		},
+		Speakers: map[string]string{"SPEAKER_00": "Alice", "SPEAKER_01": "SPEAKER_01"},
+	}
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{
+			format: FormatSRT,
+			want: "1\n00:00:00,000 --> 00:00:02,000\nAlice: Hello there.\n\n" +
+				"2\n00:00:02,500 --> 00:00:04,000\nSPEAKER_01: Fish & chips <now>\n\n",
+		},
+		{
+			format: FormatVTT,
+			want: "WEBVTT\n\n" +
+				"00:00:00.000 --> 00:00:02.000\n<v Alice>Hello there.\n\n" +
+				"00:00:02.500 --> 00:00:04.000\n<v SPEAKER_01>Fish &amp; chips &lt;now&gt;\n\n",
+		},
+		{
+			format: FormatSBV,
+			want: "0:00:00.000,0:00:02.000\nAlice: Hello there.\n\n" +
+				"0:00:02.500,0:00:04.000\nSPEAKER_01: Fish & chips <now>\n\n",
+		},
+		{
+			format: FormatTXT,
+			want:   "Alice: Hello there.\nSPEAKER_01: Fish & chips <now>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			got, err := Render(tt.format, transcript, DefaultOptions())
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Render =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTTMLLanguage(t *testing.T) {
+	tests := []struct {
+		language string
+		want     string
+	}{
+		{language: "english", want: `xml:lang="en"`},
+		{language: "pt-BR", want: `xml:lang="pt-br"`},
+		{language: "", want: `xml:lang=""`},
+		{language: "klingon", want: `xml:lang=""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			transcript := &models.Transcript{
+				Language: tt.language,
+				Segments: []models.Segment{{Start: 0, End: 1, Text: "a < b"}},
+			}
+			got, err := Render(FormatTTML, transcript, DefaultOptions())
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if !strings.Contains(string(got), tt.want) {
+				t.Errorf("TTML does not contain %s:\n%s", tt.want, got)
+			}
+			if !strings.Contains(string(got), `<p begin="00:00:00.000" end="00:00:01.000">a &lt; b</p>`) {
+				t.Errorf("TTML cue not escaped:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestBuildCues(t *testing.T) {
+	tests := []struct {
+		name      string
+		segment   models.Segment
+		opts      Options
+		wantCues  int
+		wantLines [][]string
+	}{
+		{
+			name:      "short segment is one cue",
+			segment:   models.Segment{Start: 0, End: 2, Text: "Hello there."},
+			wantCues:  1,
+			wantLines: [][]string{{"Hello there."}},
+		},
+		{
+			name:      "lines are balanced",
+			segment:   models.Segment{Start: 0, End: 6, Text: "one two three four five six"},
+			opts:      Options{MaxCharsPerLine: 20},
+			wantCues:  1,
+			wantLines: [][]string{{"one two three", "four five six"}},
+		},
+		{
+			name:      "too many lines splits the cue",
+			segment:   models.Segment{Start: 0, End: 6, Text: "aaaa bbbb cccc dddd"},
+			opts:      Options{MaxCharsPerLine: 9, MaxLines: 1},
+			wantCues:  2,
+			wantLines: [][]string{{"aaaa bbbb"}, {"cccc dddd"}},
+		},
+		{
+			name:     "too long on screen splits the cue",
+			segment:  models.Segment{Start: 0, End: 20, Text: "one two three four"},
+			opts:     Options{MaxDuration: 6},
+			wantCues: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cues := BuildCues(&models.Transcript{Segments: []models.Segment{tt.segment}}, tt.opts)
+			if len(cues) != tt.wantCues {
+				t.Fatalf("got %d cues, want %d: %+v", len(cues), tt.wantCues, cues)
+			}
+			for i, lines := range tt.wantLines {
+				if strings.Join(cues[i].Lines, "|") != strings.Join(lines, "|") {
+					t.Errorf("cue %d lines = %q, want %q", i, cues[i].Lines, lines)
+				}
+			}
+			for i := 1; i < len(cues); i++ {
+				if cues[i].Start < cues[i-1].End {
+					t.Errorf("cue %d starts at %v before cue %d ends at %v", i, cues[i].Start, i-1, cues[i-1].End)
+				}
+			}
+		})
+	}
+}
+
+func TestFixTimingStretchesShortCues(t *testing.T) {
+	cues := fixTiming([]Cue{
+		{Start: 0, End: 0.2, Lines: []string{"Hi"}},
+		{Start: 0.5, End: 0.7, Lines: []string{"there"}},
+		{Start: 5, End: 5.1, Lines: []string{"bye"}},
+	}, DefaultOptions())
+
+	// the first can only grow up to the next cue, the last gets the full minimum
+	if cues[0].End != 0.5 {
+		t.Errorf("first cue ends at %v, want 0.5", cues[0].End)
+	}
+	if cues[2].End != 6 {
+		t.Errorf("last cue ends at %v, want 6", cues[2].End)
+	}
+}
+
+func TestFixTimingReflowsFastCues(t *testing.T) {
+	tests := []struct {
+		name      string
+		cues      []Cue
+		wantTexts []string
+		wantFast  bool // a cue is still over MaxCPS
+	}{
+		{
+			name: "words move into the next cue",
+			cues: []Cue{
+				{Start: 0, End: 1, Lines: []string{"This sentence is far too long to read"}},
+				{Start: 1.2, End: 1.5, Lines: []string{"okay?"}},
+				{Start: 10, End: 11, Lines: []string{"Next."}},
+			},
+			wantTexts: []string{"This sentence is far", "too long to read okay?", "Next."},
+		},
+		{
+			name: "speakers are not mixed",
+			cues: []Cue{
+				{Start: 0, End: 1, Lines: []string{"This sentence is far too long to read"}, Speaker: "Alice"},
+				{Start: 1.2, End: 1.5, Lines: []string{"okay?"}, Speaker: "Bob"},
+			},
+			wantTexts: []string{"This sentence is far too long to read", "okay?"},
+			wantFast:  true,
+		},
+		{
+			name: "the next cue is already full",
+			cues: []Cue{
+				{Start: 0, End: 1, Lines: []string{"This sentence is far too long to read"}},
+				{Start: 1.2, End: 7, Lines: []string{"and the next cue fills both of its lines,", "right up to the limit of forty-two chars"}},
+			},
+			wantTexts: []string{"This sentence is far too long to read", "and the next cue fills both of its lines, right up to the limit of forty-two chars"},
+			wantFast:  true,
+		},
+	}
+
+	opts := DefaultOptions()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cues := fixTiming(tt.cues, opts)
+
+			var texts []string
+			fast := false
+			for i, cue := range cues {
+				texts = append(texts, cue.Text())
+				if cps := float64(len(cue.Text())) / (cue.End - cue.Start); cps > opts.MaxCPS+0.01 {
+					fast = true
+				}
+				if i+1 < len(cues) && cue.End > cues[i+1].Start {
+					t.Errorf("cue %d ends at %v after cue %d starts at %v", i, cue.End, i+1, cues[i+1].Start)
+				}
+				for _, line := range cue.Lines {
+					if len(line) > opts.MaxCharsPerLine {
+						t.Errorf("line %q is longer than %d", line, opts.MaxCharsPerLine)
+					}
+				}
+			}
+			if strings.Join(texts, "|") != strings.Join(tt.wantTexts, "|") {
+				t.Errorf("cues = %q, want %q", texts, tt.wantTexts)
+			}
+			if fast != tt.wantFast {
+				t.Errorf("a cue is over the reading speed: %v, want %v", fast, tt.wantFast)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+// Package chapters splits timed transcripts into titled chapters
+package chapters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/chat"
+)
+
+// ErrUntimed is returned for transcripts without segment timings, which can't be chaptered
+var ErrUntimed = errors.New("transcript has no timed segments")
+
+// Service defines the interface for chapter generation
+type Service interface {
+	// Generate returns the chapters of the transcript in order, the first starting at 0
+	Generate(ctx context.Context, transcript *models.Transcript) ([]models.Chapter, error)
+}
+
+// ChatGenerator finds chapters through the chat completions API
+// the transcript is sent in windows of numbered segments and the model picks the
+// segments where a new chapter starts, so boundaries always land on real timestamps
+type ChatGenerator struct {
+	Client            *chat.Client
+	Model             string
+	WindowTokens      int     // transcript tokens per request
+	MinChapterSeconds float64 // shorter chapters are merged into the one before
+}
+
+// NewChatGenerator creates a chapter generator using the given chat client
+func NewChatGenerator(client *chat.Client) *ChatGenerator {
+	return &ChatGenerator{
+		Client:            client,
+		Model:             "gpt-3.5-turbo",
+		WindowTokens:      3000,
+		MinChapterSeconds: 30, // YouTube needs 10, but chapters that short aren't useful
+	}
+}
+
+// chapterStart is one boundary in the JSON the model is asked to return
+type chapterStart struct {
+	Segment int    `json:"segment"`
+	Title   string `json:"title"`
+}
+
+// chapterResponse is the JSON object the model is asked to return
+type chapterResponse struct {
+	Chapters []chapterStart `json:"chapters"`
+}
+
+// Generate walks the transcript window by window, telling the model which chapter the
+// window continues so it only starts a new one where the topic actually changes
+func (g *ChatGenerator) Generate(ctx context.Context, transcript *models.Transcript) ([]models.Chapter, error) {
+	var segments []models.Segment
+	for _, segment := range transcript.Segments {
+		if strings.TrimSpace(segment.Text) != "" {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) == 0 || segments[len(segments)-1].End <= 0 {
+		return nil, ErrUntimed
+	}
+
+	windowTokens := g.WindowTokens
+	if windowTokens <= 0 {
+		windowTokens = 3000
+	}
+
+	var starts []chapterStart
+	for _, window := range windows(segments, windowTokens) {
+		previous := ""
+		if len(starts) > 0 {
+			previous = starts[len(starts)-1].Title
+		}
+
+		found, err := g.window(ctx, segments[window[0]:window[1]], window[0], previous, transcript.Language)
+		if err != nil {
+			return nil, err
+		}
+		starts = append(starts, found...)
+	}
+
+	end := max(transcript.Duration, segments[len(segments)-1].End)
+	return buildChapters(segments, starts, end, g.MinChapterSeconds), nil
+}
+
+// windows splits segments into consecutive [from, to) ranges of at most maxTokens
+func windows(segments []models.Segment, maxTokens int) [][2]int {
+	var ranges [][2]int
+	from, tokens := 0, 0
+	for i, segment := range segments {
+		cost := chat.EstimateTokens(segment.Text) + 4 // the "[id] m:ss" prefix
+		if i > from && tokens+cost > maxTokens {
+			ranges = append(ranges, [2]int{from, i})
+			from, tokens = i, 0
+		}
+		tokens += cost
+	}
+	return append(ranges, [2]int{from, len(segments)})
+}
+
+// window asks for the chapter starts in one window of segments, numbered from offset
+func (g *ChatGenerator) window(ctx context.Context, segments []models.Segment, offset int, previous string, language string) ([]chapterStart, error) {
+	var lines strings.Builder
+	for i, segment := range segments {
+		fmt.Fprintf(&lines, "[%d] %s %s\n", offset+i, models.FormatTime(segment.Start), strings.TrimSpace(segment.Text))
+	}
+
+	intro := "This is the start of the video, so the first chapter starts at segment 0."
+	if offset > 0 {
+		intro = fmt.Sprintf("This excerpt continues from earlier in the video. Only start a chapter at segment %d if the topic changes there.", offset)
+		if previous != "" {
+			intro = fmt.Sprintf("This excerpt continues the chapter %q. Only start a chapter at segment %d if the topic changes there.", previous, offset)
+		}
+	}
+	titleLanguage := "the transcript's language"
+	if language != "" {
+		titleLanguage = fmt.Sprintf("language %q", language)
+	}
+
+	request := chat.Request{
+		Model: g.Model,
+		Messages: []chat.Message{
+			{
+				Role: "system",
+				Content: "You split video transcripts into chapters for viewers to navigate by. You receive numbered transcript segments with their start times " +
+					`and reply with a JSON object {"chapters": [{"segment": <id>, "title": <title>}]} listing the segment where each new chapter starts. ` +
+					"Start chapters where the topic changes, aiming for chapters a few minutes long, and give each a short title of at most six words.",
+			},
+			{
+				Role:    "user",
+				Content: fmt.Sprintf("%s Write the titles in %s.\n\n%s", intro, titleLanguage, lines.String()),
+			},
+		},
+		Temperature:    0.2, // Low temperature keeps boundaries stable between runs
+		ResponseFormat: &chat.ResponseFormat{Type: "json_object"},
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < 2; attempt++ {
+		content, err := g.Client.Complete(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		starts, err := parseStarts(content, offset, offset+len(segments))
+		if err == nil {
+			return starts, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// parseStarts decodes the model's chapter starts, dropping ones outside [from, to) or without a title
+func parseStarts(content string, from int, to int) ([]chapterStart, error) {
+	var response chapterResponse
+	if err := json.Unmarshal([]byte(content), &response); err != nil {
+		return nil, fmt.Errorf("failed to decode chapters: %w", err)
+	}
+
+	var starts []chapterStart
+	for _, start := range response.Chapters {
+		start.Title = strings.TrimSpace(start.Title)
+		if start.Segment < from || start.Segment >= to || start.Title == "" {
+			continue
+		}
+		starts = append(starts, start)
+	}
+	sort.SliceStable(starts, func(i, j int) bool { return starts[i].Segment < starts[j].Segment })
+	return starts, nil
+}
+
+// buildChapters turns chapter starts into chapters covering the whole video
+// the first chapter starts at 0 and chapters shorter than minSeconds are folded into the previous one
+func buildChapters(segments []models.Segment, starts []chapterStart, end float64, minSeconds float64) []models.Chapter {
+	var chapters []models.Chapter
+	for _, start := range starts {
+		at := segments[start.Segment].Start
+		if len(chapters) == 0 {
+			at = 0
+		} else if at-chapters[len(chapters)-1].Start < minSeconds {
+			continue
+		}
+		chapters = append(chapters, models.Chapter{Start: at, Title: start.Title})
+	}
+	if len(chapters) == 0 {
+		// the model found nothing to split, the whole video is one chapter
+		chapters = append(chapters, models.Chapter{Start: 0, Title: "Full video"})
+	}
+
+	// the last chapter too short means merging it backwards
+	if n := len(chapters); n > 1 && end-chapters[n-1].Start < minSeconds {
+		chapters = chapters[:n-1]
+	}
+	for i := range chapters {
+		if i+1 < len(chapters) {
+			chapters[i].End = chapters[i+1].Start
+		} else {
+			chapters[i].End = end
+		}
+	}
+	return chapters
+}
+
+// YouTube renders chapters the way YouTube reads them from a video description,
+// one "00:00 Title" line each; YouTube only shows them with at least three chapters of 10 seconds or more
+func YouTube(chapters []models.Chapter) string {
+	hours := len(chapters) > 0 && chapters[len(chapters)-1].Start >= 3600
+	var b strings.Builder
+	for _, chapter := range chapters {
+		total := int(chapter.Start)
+		if hours {
+			fmt.Fprintf(&b, "%d:%02d:%02d %s\n", total/3600, total/60%60, total%60, chapter.Title)
+		} else {
+			fmt.Fprintf(&b, "%02d:%02d %s\n", total/60, total%60, chapter.Title)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,217 @@
+package chapters
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/internal/models/modelstest"
+	"github.com/ahmadbasyouni10/videogpt/pkg/chat"
+)
+
+func TestBuildChapters(t *testing.T) {
+	segments := modelstest.Segments(60, slices.Repeat([]string{"words"}, 10)...)
+
+	tests := []struct {
+		name   string
+		starts []chapterStart
+		end    float64
+		want   []models.Chapter
+	}{
+		{
+			name: "nothing found is one chapter",
+			end:  600,
+			want: []models.Chapter{{Start: 0, End: 600, Title: "Full video"}},
+		},
+		{
+			name:   "first chapter starts at zero",
+			starts: []chapterStart{{Segment: 2, Title: "Intro"}, {Segment: 5, Title: "Demo"}},
+			end:    600,
+			want: []models.Chapter{
+				{Start: 0, End: 300, Title: "Intro"},
+				{Start: 300, End: 600, Title: "Demo"},
+			},
+		},
+		{
+			name:   "short chapters fold into the previous one",
+			starts: []chapterStart{{Segment: 0, Title: "Intro"}, {Segment: 1, Title: "Agenda"}, {Segment: 1, Title: "Again"}, {Segment: 4, Title: "Demo"}},
+			end:    600,
+			want: []models.Chapter{
+				{Start: 0, End: 60, Title: "Intro"},
+				{Start: 60, End: 240, Title: "Agenda"},
+				{Start: 240, End: 600, Title: "Demo"},
+			},
+		},
+		{
+			name:   "short last chapter merges backwards",
+			starts: []chapterStart{{Segment: 0, Title: "Intro"}, {Segment: 9, Title: "Outro"}},
+			end:    560,
+			want:   []models.Chapter{{Start: 0, End: 560, Title: "Intro"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildChapters(segments, tt.starts, tt.end, 30)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("chapters = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStarts(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []chapterStart
+		wantErr bool
+	}{
+		{
+			name:    "sorted and trimmed",
+			content: `{"chapters": [{"segment": 7, "title": " Demo "}, {"segment": 5, "title": "Setup"}]}`,
+			want:    []chapterStart{{Segment: 5, Title: "Setup"}, {Segment: 7, Title: "Demo"}},
+		},
+		{
+			name:    "outside the window or untitled",
+			content: `{"chapters": [{"segment": 4, "title": "Before"}, {"segment": 6, "title": ""}, {"segment": 10, "title": "After"}]}`,
+			want:    nil,
+		},
+		{
+			name:    "not json",
+			content: "Chapter 1: Intro",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStarts(tt.content, 5, 10)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("starts = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindows(t *testing.T) {
+	// "words" is 2 tokens, plus 4 for the prefix
+	segments := modelstest.Segments(60, slices.Repeat([]string{"words"}, 5)...)
+
+	tests := []struct {
+		name string
+		max  int
+		want [][2]int
+	}{
+		{name: "all in one", max: 100, want: [][2]int{{0, 5}}},
+		{name: "two per window", max: 12, want: [][2]int{{0, 2}, {2, 4}, {4, 5}}},
+		{name: "at least one per window", max: 1, want: [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 4}, {4, 5}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := windows(segments, tt.max); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("windows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYouTube(t *testing.T) {
+	tests := []struct {
+		name     string
+		chapters []models.Chapter
+		want     string
+	}{
+		{
+			name:     "minutes",
+			chapters: []models.Chapter{{Start: 0, Title: "Intro"}, {Start: 95, Title: "Demo"}},
+			want:     "00:00 Intro\n01:35 Demo\n",
+		},
+		{
+			name:     "hours once the video is long enough",
+			chapters: []models.Chapter{{Start: 0, Title: "Intro"}, {Start: 3725, Title: "Q&A"}},
+			want:     "0:00:00 Intro\n1:02:05 Q&A\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := YouTube(tt.chapters); got != tt.want {
+				t.Errorf("YouTube = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateWindows(t *testing.T) {
+	tests := []struct {
+		name       string
+		replies    []string // one per window
+		wantIntros []string
+	}{
+		{
+			name:    "later windows continue the previous chapter",
+			replies: []string{`{"chapters": [{"segment": 0, "title": "Intro"}]}`, `{"chapters": []}`},
+			wantIntros: []string{
+				"This is the start of the video",
+				`This excerpt continues the chapter "Intro". Only start a chapter at segment 2`,
+			},
+		},
+		{
+			name:    "no chapter yet is not the start of the video",
+			replies: []string{`{"chapters": []}`, `{"chapters": [{"segment": 3, "title": "Demo"}]}`},
+			wantIntros: []string{
+				"This is the start of the video",
+				"This excerpt continues from earlier in the video. Only start a chapter at segment 2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prompts []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var request chat.Request
+				json.NewDecoder(r.Body).Decode(&request)
+				prompts = append(prompts, request.Messages[len(request.Messages)-1].Content)
+
+				reply := tt.replies[min(len(prompts), len(tt.replies))-1]
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"choices": []interface{}{map[string]interface{}{"message": map[string]string{"content": reply}}},
+				})
+			}))
+			defer server.Close()
+
+			client := chat.NewClient("test-key")
+			client.BaseURL = server.URL
+			generator := NewChatGenerator(client)
+			generator.WindowTokens = 12 // two segments per window
+
+			chapters, err := generator.Generate(context.Background(), &models.Transcript{Segments: modelstest.Segments(60, slices.Repeat([]string{"words"}, 4)...)})
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			if len(prompts) != len(tt.wantIntros) {
+				t.Fatalf("sent %d prompts, want %d", len(prompts), len(tt.wantIntros))
+			}
+			for i, intro := range tt.wantIntros {
+				if !strings.HasPrefix(prompts[i], intro) {
+					t.Errorf("prompt %d = %q, want it to start with %q", i, prompts[i], intro)
+				}
+			}
+			if chapters[0].Start != 0 {
+				t.Errorf("first chapter starts at %v, want 0", chapters[0].Start)
+			}
+		})
+	}
+}
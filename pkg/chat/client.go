@@ -0,0 +1,141 @@
+// Package chat is a small client for OpenAI-compatible chat completions
+// shared by summarization, translation and anything else that prompts a chat model
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ahmadbasyouni10/videogpt/pkg/httpclient"
+)
+
+// DefaultBaseURL is OpenAI's API
+const DefaultBaseURL = "https://api.openai.com/v1"
+
+// Message represents a message in a chat conversation
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ResponseFormat asks the model for a particular output shape, e.g. {"type": "json_object"},
+// or {"type": "json_schema"} with JSONSchema set for output that must match a schema
+type ResponseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+// JSONSchema is a named schema for structured outputs
+// with Strict the model can only produce matching JSON, which needs every property
+// required and additionalProperties false on every object
+type JSONSchema struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+	Strict bool            `json:"strict,omitempty"`
+}
+
+// Request represents a request to the chat completions API
+type Request struct {
+	Model          string          `json:"model"`
+	Messages       []Message       `json:"messages"`
+	Temperature    float64         `json:"temperature"`
+	TopP           *float64        `json:"top_p,omitempty"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+// Response represents the response from the chat completions API
+type Response struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// completionAllowance is what we budget for the reply when estimating a request's tokens,
+// OpenAI counts max_tokens against the quota up front and we never set it
+const completionAllowance = 1000
+
+// EstimateTokens roughly counts the tokens in text, about 4 characters per token for English
+// it is only used for rate limiting and chunking, so it leans towards overestimating
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// EstimateTokens estimates what the request will count against a tokens-per-minute quota
+func (r Request) EstimateTokens() int {
+	tokens := completionAllowance
+	for _, message := range r.Messages {
+		tokens += EstimateTokens(message.Content) + 4 // per-message overhead
+	}
+	return tokens
+}
+
+// Client calls the chat completions endpoint
+type Client struct {
+	APIKey  string
+	BaseURL string
+	HTTP    *httpclient.Client // retries, backoff and typed errors
+}
+
+// NewClient creates a new chat client for OpenAI's API
+func NewClient(apiKey string) *Client {
+	return &Client{
+		APIKey:  apiKey,
+		BaseURL: DefaultBaseURL,
+		HTTP:    httpclient.New(60 * time.Second), // Default timeout of 60 seconds per attempt
+	}
+}
+
+// Complete sends the request and returns the content of the first choice
+func (c *Client) Complete(ctx context.Context, request Request) (string, error) {
+	// self-hosted OpenAI-compatible servers often run without auth, OpenAI never does
+	if c.APIKey == "" && c.BaseURL == DefaultBaseURL {
+		return "", errors.New("OpenAI API key is required")
+	}
+
+	// Convert request to JSON
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Create HTTP request
+	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	// Set headers
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.APIKey))
+	}
+
+	// Send the request, retrying rate limits and server errors
+	responseBody, err := c.HTTP.DoWeighted(req, request.EstimateTokens())
+	if err != nil {
+		return "", err
+	}
+
+	// Parse the response
+	var result Response
+	if err := json.Unmarshal(responseBody, &result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	// Check if we got any choices back
+	if len(result.Choices) == 0 {
+		return "", errors.New("no completion was generated")
+	}
+
+	return result.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,133 @@
+// Package embeddings turns text into vectors for semantic search
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/ahmadbasyouni10/videogpt/pkg/chat"
+	"github.com/ahmadbasyouni10/videogpt/pkg/httpclient"
+)
+
+// DefaultBaseURL is OpenAI's API
+const DefaultBaseURL = "https://api.openai.com/v1"
+
+// Service defines the interface for embedding services
+type Service interface {
+	// Embed returns one vector per text, in the same order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Model names the embedding model, vectors from different models can't be compared
+	Model() string
+}
+
+// OpenAIService embeds text through an OpenAI-compatible /embeddings endpoint,
+// which OpenAI, Ollama, LocalAI, vLLM and most self-hosted servers provide
+type OpenAIService struct {
+	APIKey    string
+	BaseURL   string
+	ModelName string
+	BatchSize int // texts per request
+	HTTP      *httpclient.Client
+}
+
+// NewOpenAIService creates an embedding service for OpenAI's API
+func NewOpenAIService(apiKey string) *OpenAIService {
+	return &OpenAIService{
+		APIKey:    apiKey,
+		BaseURL:   DefaultBaseURL,
+		ModelName: "text-embedding-3-small",
+		BatchSize: 64,
+		HTTP:      httpclient.New(60 * time.Second),
+	}
+}
+
+// embeddingRequest is the body of an /embeddings request
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// embeddingResponse is the part of the /embeddings response we use
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Model returns the embedding model's name
+func (s *OpenAIService) Model() string {
+	return s.ModelName
+}
+
+// Embed embeds texts in batches of BatchSize
+func (s *OpenAIService) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	// self-hosted OpenAI-compatible servers often run without auth, OpenAI never does
+	if s.APIKey == "" && s.BaseURL == DefaultBaseURL {
+		return nil, errors.New("OpenAI API key is required")
+	}
+
+	batchSize := s.BatchSize
+	if batchSize <= 0 {
+		batchSize = 64
+	}
+
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		batch := texts[start:min(start+batchSize, len(texts))]
+		embedded, err := s.embedBatch(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, embedded...)
+	}
+	return vectors, nil
+}
+
+// embedBatch sends one /embeddings request
+func (s *OpenAIService) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	jsonData, err := json.Marshal(embeddingRequest{Model: s.ModelName, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.BaseURL+"/embeddings", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.APIKey != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.APIKey))
+	}
+
+	tokens := 0
+	for _, text := range texts {
+		tokens += chat.EstimateTokens(text)
+	}
+	responseBody, err := s.HTTP.DoWeighted(req, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	var result embeddingResponse
+	if err := json.Unmarshal(responseBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("got %d embeddings for %d texts", len(result.Data), len(texts))
+	}
+
+	// the API returns them with their input index, don't rely on the order
+	sort.Slice(result.Data, func(i, j int) bool { return result.Data[i].Index < result.Data[j].Index })
+	vectors := make([][]float32, len(texts))
+	for i, item := range result.Data {
+		vectors[i] = item.Embedding
+	}
+	return vectors, nil
+}
@@ -0,0 +1,174 @@
+// Package fallback keeps track of which AI providers are healthy
+// the composite services in transcription and summarization use it to skip
+// providers that keep failing instead of waiting on them for every request
+package fallback
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnavailable is returned when every provider in a chain failed or is switched off
+var ErrUnavailable = errors.New("all providers are unavailable")
+
+// ChainError collects why each provider in a chain failed
+// it matches ErrUnavailable and every provider error with errors.Is / errors.As
+type ChainError struct {
+	Errors []error
+}
+
+func (e *ChainError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%v: %s", ErrUnavailable, strings.Join(messages, "; "))
+}
+
+func (e *ChainError) Unwrap() []error {
+	return append([]error{ErrUnavailable}, e.Errors...)
+}
+
+// Breaker states
+const (
+	StateClosed   = "closed"    // healthy, requests go through
+	StateOpen     = "open"      // failing, requests are skipped until the cooldown ends
+	StateHalfOpen = "half_open" // cooldown is over, one trial request decides
+)
+
+// Breaker is a circuit breaker for one provider
+// after FailureThreshold failures in a row it opens for Cooldown, then lets
+// a single trial request through, closing again if that succeeds
+type Breaker struct {
+	Name             string
+	FailureThreshold int
+	Cooldown         time.Duration
+
+	mu          sync.Mutex
+	state       string
+	failures    int
+	openedAt    time.Time
+	trial       bool // a half-open trial request is in flight
+	lastError   string
+	lastFailure time.Time
+	lastSuccess time.Time
+}
+
+// NewBreaker creates a breaker that opens after 3 failures for 30 seconds
+func NewBreaker(name string) *Breaker {
+	return &Breaker{
+		Name:             name,
+		FailureThreshold: 3,
+		Cooldown:         30 * time.Second,
+		state:            StateClosed,
+	}
+}
+
+// Allow reports whether a request may go to the provider right now
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if time.Since(b.openedAt) < b.Cooldown {
+			return false
+		}
+		b.state = StateHalfOpen
+		b.trial = true
+		return true
+	case StateHalfOpen:
+		// only one trial at a time, the rest keep falling back until it reports
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	}
+	return true
+}
+
+// Success records a successful request and closes the breaker
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = StateClosed
+	b.failures = 0
+	b.trial = false
+	b.lastSuccess = time.Now()
+}
+
+// Failure records a failed request, opening the breaker once the threshold is reached
+// a failed half-open trial opens it again straight away
+func (b *Breaker) Failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.lastFailure = time.Now()
+	if err != nil {
+		b.lastError = err.Error()
+	}
+
+	threshold := max(b.FailureThreshold, 1)
+	if b.state == StateHalfOpen || b.failures >= threshold {
+		b.state = StateOpen
+		b.openedAt = time.Now()
+	}
+	b.trial = false
+}
+
+// Release gives back a trial slot without counting a success or a failure,
+// for requests that ended for reasons that say nothing about the provider
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+}
+
+// Health is a snapshot of a breaker for the diagnostics endpoint
+type Health struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastFailure         *time.Time `json:"last_failure,omitempty"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	RetryAt             *time.Time `json:"retry_at,omitempty"` // when an open breaker lets a trial through
+}
+
+// Health returns the breaker's current state
+func (b *Breaker) Health() Health {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	health := Health{
+		Name:                b.Name,
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastError,
+	}
+	if !b.lastFailure.IsZero() {
+		lastFailure := b.lastFailure
+		health.LastFailure = &lastFailure
+	}
+	if !b.lastSuccess.IsZero() {
+		lastSuccess := b.lastSuccess
+		health.LastSuccess = &lastSuccess
+	}
+	if b.state == StateOpen {
+		retryAt := b.openedAt.Add(b.Cooldown)
+		health.RetryAt = &retryAt
+	}
+	return health
+}
+
+// Reporter is implemented by the composite services so diagnostics can list their providers
+type Reporter interface {
+	Health() []Health
+}
@@ -0,0 +1,156 @@
+package fallback
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	// step is one call on the breaker, want is what Allow should return
+	type step struct {
+		call string // allow, success, failure or release
+		want bool
+	}
+
+	tests := []struct {
+		name      string
+		cooldown  time.Duration
+		steps     []step
+		wantState string
+	}{
+		{
+			name:     "opens at the threshold",
+			cooldown: time.Hour,
+			steps: []step{
+				{call: "failure"}, {call: "failure"},
+				{call: "allow", want: true},
+				{call: "failure"},
+				{call: "allow", want: false},
+			},
+			wantState: StateOpen,
+		},
+		{
+			name:     "a success resets the count",
+			cooldown: time.Hour,
+			steps: []step{
+				{call: "failure"}, {call: "failure"}, {call: "success"}, {call: "failure"}, {call: "failure"},
+				{call: "allow", want: true},
+			},
+			wantState: StateClosed,
+		},
+		{
+			name: "half-open lets a single trial through",
+			steps: []step{
+				{call: "failure"}, {call: "failure"}, {call: "failure"},
+				{call: "allow", want: true},
+				{call: "allow", want: false},
+				{call: "allow", want: false},
+			},
+			wantState: StateHalfOpen,
+		},
+		{
+			name: "a successful trial closes it",
+			steps: []step{
+				{call: "failure"}, {call: "failure"}, {call: "failure"},
+				{call: "allow", want: true},
+				{call: "success"},
+				{call: "allow", want: true},
+				{call: "allow", want: true},
+			},
+			wantState: StateClosed,
+		},
+		{
+			name: "a failed trial opens it again",
+			steps: []step{
+				{call: "failure"}, {call: "failure"}, {call: "failure"},
+				{call: "allow", want: true},
+				{call: "failure"},
+			},
+			wantState: StateOpen,
+		},
+		{
+			name: "release gives the trial back",
+			steps: []step{
+				{call: "failure"}, {call: "failure"}, {call: "failure"},
+				{call: "allow", want: true},
+				{call: "release"},
+				{call: "allow", want: true},
+				{call: "allow", want: false},
+			},
+			wantState: StateHalfOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := NewBreaker("whisper")
+			breaker.Cooldown = tt.cooldown
+
+			for i, step := range tt.steps {
+				switch step.call {
+				case "allow":
+					if got := breaker.Allow(); got != step.want {
+						t.Fatalf("step %d: Allow = %v, want %v", i, got, step.want)
+					}
+				case "success":
+					breaker.Success()
+				case "failure":
+					breaker.Failure(errors.New("503 Service Unavailable"))
+				case "release":
+					breaker.Release()
+				}
+			}
+
+			if state := breaker.Health().State; state != tt.wantState {
+				t.Errorf("state = %s, want %s", state, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestBreakerHealth(t *testing.T) {
+	breaker := NewBreaker("whisper")
+	for i := 0; i < breaker.FailureThreshold; i++ {
+		breaker.Failure(errors.New("503 Service Unavailable"))
+	}
+
+	health := breaker.Health()
+	if health.ConsecutiveFailures != 3 || health.LastError != "503 Service Unavailable" {
+		t.Errorf("health = %+v, want 3 failures and the last error", health)
+	}
+	if health.RetryAt == nil || health.LastFailure == nil {
+		t.Fatalf("health = %+v, want the last failure and when to retry", health)
+	}
+	if wait := health.RetryAt.Sub(*health.LastFailure); wait < breaker.Cooldown || wait > breaker.Cooldown+time.Second {
+		t.Errorf("retry %v after the last failure, want the %v cooldown", wait, breaker.Cooldown)
+	}
+	if health.LastSuccess != nil {
+		t.Errorf("last success = %v, want none", health.LastSuccess)
+	}
+}
+
+// statusError stands in for a provider's typed API error
+type statusError struct{ status int }
+
+func (e *statusError) Error() string { return fmt.Sprintf("status %d", e.status) }
+
+func TestChainError(t *testing.T) {
+	outage := &statusError{status: 503}
+	err := error(&ChainError{Errors: []error{
+		fmt.Errorf("whisper: %w", outage),
+		errors.New("deepgram: circuit open"),
+	}})
+
+	if !errors.Is(err, ErrUnavailable) {
+		t.Error("ChainError does not match ErrUnavailable")
+	}
+	var status *statusError
+	if !errors.As(err, &status) || status != outage {
+		t.Errorf("ChainError does not unwrap to the provider error, got %v", status)
+	}
+	if want := "all providers are unavailable: whisper: status 503; deepgram: circuit open"; err.Error() != want {
+		t.Errorf("err = %q, want %q", err, want)
+	}
+}
@@ -0,0 +1,224 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// Silence is a stretch of audio below the silence threshold, in seconds
+type Silence struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// Midpoint returns the middle of the silence, which is the safest place to cut
+func (s Silence) Midpoint() float64 {
+	return (s.Start + s.End) / 2
+}
+
+// SilenceOptions tunes the silencedetect filter
+type SilenceOptions struct {
+	NoiseDB     float64 // anything quieter than this counts as silence, e.g. -35
+	MinDuration float64 // shortest silence to report, in seconds
+}
+
+// DefaultSilenceOptions are good enough to find pauses between sentences
+var DefaultSilenceOptions = SilenceOptions{NoiseDB: -35, MinDuration: 0.5}
+
+// AudioChunk is one piece of a split audio file
+// Start is where the chunk begins in the original file so timestamps can be shifted back
+type AudioChunk struct {
+	Path     string  `json:"path"`
+	Start    float64 `json:"start"`
+	Duration float64 `json:"duration"`
+}
+
+var (
+	silenceStartPattern = regexp.MustCompile(`silence_start: (-?[\d.]+)`)
+	silenceEndPattern   = regexp.MustCompile(`silence_end: (-?[\d.]+)`)
+)
+
+// DetectSilence runs the silencedetect filter over the file and returns the silent stretches
+func (p *Processor) DetectSilence(ctx context.Context, audioPath string, opts SilenceOptions) ([]Silence, error) {
+	if !p.Capabilities.HasFilter("silencedetect") {
+		return nil, fmt.Errorf("silencedetect filter not available in this ffmpeg build")
+	}
+
+	// silencedetect logs to stderr, -f null throws the decoded audio away
+	output, err := p.Runner.CombinedOutput(ctx,
+		"ffmpeg",
+		"-hide_banner",
+		"-nostats",
+		"-i", audioPath,
+		"-vn",
+		"-af", fmt.Sprintf("silencedetect=noise=%gdB:d=%g", opts.NoiseDB, opts.MinDuration),
+		"-f", "null",
+		"-",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect silence: %w", err)
+	}
+
+	return parseSilences(string(output)), nil
+}
+
+// parseSilences pairs up the silence_start and silence_end lines from silencedetect
+// a trailing silence_start without an end means the file ends in silence
+func parseSilences(output string) []Silence {
+	starts := silenceStartPattern.FindAllStringSubmatch(output, -1)
+	ends := silenceEndPattern.FindAllStringSubmatch(output, -1)
+
+	silences := make([]Silence, 0, len(starts))
+	for i, start := range starts {
+		silence := Silence{End: -1}
+		silence.Start, _ = strconv.ParseFloat(start[1], 64)
+		if silence.Start < 0 {
+			silence.Start = 0
+		}
+		if i < len(ends) {
+			silence.End, _ = strconv.ParseFloat(ends[i][1], 64)
+		}
+		silences = append(silences, silence)
+	}
+	return silences
+}
+
+// silentRatio is how much of a file has to be silence before we call it silent
+// a little slack covers clicks and room noise at the very start or end
+const silentRatio = 0.98
+
+// HasSpeech reports whether a file has any audio worth transcribing
+// it is false when there is no audio stream or the audio is (almost) all silence
+func (p *Processor) HasSpeech(ctx context.Context, path string) (bool, error) {
+	if !p.HasAudio(ctx, path) {
+		return false, nil
+	}
+
+	duration, err := p.GetVideoDuration(ctx, path)
+	if err != nil {
+		return false, err
+	}
+	if duration <= 0 {
+		return false, nil
+	}
+
+	silences, err := p.DetectSilence(ctx, path, DefaultSilenceOptions)
+	if err != nil {
+		return false, err
+	}
+
+	silent := 0.0
+	for _, silence := range silences {
+		end := silence.End
+		if end < 0 {
+			// still silent when the file ended
+			end = duration
+		}
+		silent += end - silence.Start
+	}
+
+	return silent/duration < silentRatio, nil
+}
+
+// SplitAudio splits an audio file into chunks that are each under maxBytes
+// cuts are placed in the middle of silences so words are not chopped in half,
+// and only fall back to a hard cut when a stretch has no pause at all
+func (p *Processor) SplitAudio(ctx context.Context, audioPath string, maxBytes int64) ([]AudioChunk, error) {
+	info, err := os.Stat(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat audio file: %w", err)
+	}
+
+	duration, err := p.GetVideoDuration(ctx, audioPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// small enough to send as is
+	if info.Size() <= maxBytes {
+		return []AudioChunk{{Path: audioPath, Start: 0, Duration: duration}}, nil
+	}
+
+	// work out how many seconds fit in a chunk, leaving 10% headroom for bitrate variation
+	bytesPerSecond := float64(info.Size()) / duration
+	maxChunkSeconds := float64(maxBytes) * 0.9 / bytesPerSecond
+
+	silences, err := p.DetectSilence(ctx, audioPath, DefaultSilenceOptions)
+	if err != nil {
+		// not fatal, we just cut at fixed lengths
+		fmt.Printf("Failed to detect silence, splitting at fixed lengths: %v\n", err)
+	}
+
+	cuts := planCuts(duration, maxChunkSeconds, silences)
+
+	fileName := filepath.Base(audioPath)
+	ext := filepath.Ext(fileName)
+	fileNameWithoutExt := fileName[:len(fileName)-len(ext)]
+
+	chunks := make([]AudioChunk, 0, len(cuts))
+	start := 0.0
+	for i, end := range cuts {
+		chunkPath := filepath.Join(p.TempDir, fmt.Sprintf("%s_chunk%03d%s", fileNameWithoutExt, i, ext))
+
+		// -ss before -i seeks fast, -c copy avoids re-encoding
+		_, err := p.Runner.CombinedOutput(ctx,
+			"ffmpeg",
+			"-ss", fmt.Sprintf("%.3f", start),
+			"-t", fmt.Sprintf("%.3f", end-start),
+			"-i", audioPath,
+			"-c", "copy",
+			"-y",
+			chunkPath,
+		)
+		if err != nil {
+			for _, chunk := range chunks {
+				os.Remove(chunk.Path)
+			}
+			return nil, fmt.Errorf("failed to split audio: %w", err)
+		}
+
+		chunks = append(chunks, AudioChunk{Path: chunkPath, Start: start, Duration: end - start})
+		start = end
+	}
+
+	return chunks, nil
+}
+
+// planCuts picks the end time of each chunk
+// for each chunk we take the last silence midpoint that still fits, but ignore
+// ones in the first half of the window so we don't end up with lots of tiny chunks
+func planCuts(duration, maxChunkSeconds float64, silences []Silence) []float64 {
+	var cuts []float64
+	start := 0.0
+	for duration-start > maxChunkSeconds {
+		limit := start + maxChunkSeconds
+		cut := limit
+		for _, silence := range silences {
+			if silence.End < 0 {
+				continue
+			}
+			midpoint := silence.Midpoint()
+			if midpoint > start+maxChunkSeconds/2 && midpoint <= limit {
+				cut = midpoint
+			}
+		}
+		cuts = append(cuts, cut)
+		start = cut
+	}
+	return append(cuts, duration)
+}
+
+// RemoveChunks deletes chunk files created by SplitAudio
+// a single chunk is the original file, which the caller cleans up itself
+func RemoveChunks(chunks []AudioChunk) {
+	if len(chunks) <= 1 {
+		return
+	}
+	for _, chunk := range chunks {
+		os.Remove(chunk.Path)
+	}
+}
@@ -0,0 +1,163 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Capabilities describes what the installed ffmpeg build can do
+// it is probed once at startup so missing pieces show up in the logs and the
+// diagnostics endpoint instead of failing on every request
+type Capabilities struct {
+	FFmpegVersion  string          `json:"ffmpeg_version"`
+	HasFFprobe     bool            `json:"has_ffprobe"`
+	FFprobeVersion string          `json:"ffprobe_version,omitempty"`
+	Encoders       map[string]bool `json:"encoders"` // nil when the list couldn't be read
+	Filters        map[string]bool `json:"filters"`
+	Formats        map[string]bool `json:"formats"`
+	Warnings       []string        `json:"warnings,omitempty"`
+	DetectedAt     time.Time       `json:"detected_at"`
+}
+
+// HasEncoder reports whether the encoder is compiled in
+// a nil Capabilities means we never probed, and a nil list means the probe failed,
+// either way we don't know so we assume it is there
+func (c *Capabilities) HasEncoder(name string) bool {
+	return c == nil || c.Encoders == nil || c.Encoders[name]
+}
+
+// HasFilter reports whether the filter is compiled in
+func (c *Capabilities) HasFilter(name string) bool {
+	return c == nil || c.Filters == nil || c.Filters[name]
+}
+
+// HasFormat reports whether the muxer/demuxer is compiled in
+func (c *Capabilities) HasFormat(name string) bool {
+	return c == nil || c.Formats == nil || c.Formats[name]
+}
+
+// CanProbe reports whether ffprobe is available
+func (c *Capabilities) CanProbe() bool {
+	return c == nil || c.HasFFprobe
+}
+
+// DetectCapabilities runs ffmpeg -version, -encoders, -filters and -formats and ffprobe -version
+// only a missing ffmpeg is an error, everything else just ends up as a warning
+// a list that can't be read is left nil so the Has* checks don't switch features off over it
+func DetectCapabilities(ctx context.Context, runner Runner) (*Capabilities, error) {
+	caps := &Capabilities{
+		DetectedAt: time.Now(),
+	}
+
+	output, err := runner.Output(ctx, "ffmpeg", "-hide_banner", "-version")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run ffmpeg -version: %w", err)
+	}
+	caps.FFmpegVersion = parseVersion(string(output))
+
+	if output, err := runner.Output(ctx, "ffprobe", "-hide_banner", "-version"); err == nil {
+		caps.HasFFprobe = true
+		caps.FFprobeVersion = parseVersion(string(output))
+	} else {
+		caps.Warnings = append(caps.Warnings, "ffprobe not found, falling back to ffmpeg for probing")
+	}
+
+	if output, err := runner.Output(ctx, "ffmpeg", "-hide_banner", "-encoders"); err == nil {
+		caps.Encoders = parseCodecList(string(output))
+	} else {
+		caps.Warnings = append(caps.Warnings, fmt.Sprintf("failed to list encoders: %v", err))
+	}
+
+	if output, err := runner.Output(ctx, "ffmpeg", "-hide_banner", "-filters"); err == nil {
+		caps.Filters = parseFilterList(string(output))
+	} else {
+		caps.Warnings = append(caps.Warnings, fmt.Sprintf("failed to list filters: %v", err))
+	}
+
+	if output, err := runner.Output(ctx, "ffmpeg", "-hide_banner", "-formats"); err == nil {
+		caps.Formats = parseFormatList(string(output))
+	} else {
+		caps.Warnings = append(caps.Warnings, fmt.Sprintf("failed to list formats: %v", err))
+	}
+
+	// call out the encoders we actually rely on, when we know what is there
+	if !caps.HasEncoder("libmp3lame") {
+		caps.Warnings = append(caps.Warnings, "libmp3lame not available, audio will be extracted as AAC")
+	}
+	if !caps.HasEncoder("mjpeg") {
+		caps.Warnings = append(caps.Warnings, "mjpeg encoder not available, thumbnails are disabled")
+	}
+
+	return caps, nil
+}
+
+// parseVersion pulls "6.1.1" out of "ffmpeg version 6.1.1 Copyright (c) ..."
+func parseVersion(output string) string {
+	firstLine := strings.SplitN(strings.TrimSpace(output), "\n", 2)[0]
+	fields := strings.Fields(firstLine)
+	for i, field := range fields {
+		if field == "version" && i+1 < len(fields) {
+			return fields[i+1]
+		}
+	}
+	return firstLine
+}
+
+// parseCodecList parses the output of -encoders
+// the legend ends with a " ------" line, after that each line is "<flags> <name> <description>"
+func parseCodecList(output string) map[string]bool {
+	names := map[string]bool{}
+	inList := false
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if !inList {
+			inList = strings.HasPrefix(fields[0], "---")
+			continue
+		}
+		if len(fields) >= 2 {
+			names[fields[1]] = true
+		}
+	}
+	return names
+}
+
+// parseFilterList parses the output of -filters
+// filter lines look like " ... silencedetect     A->N       Detect silence."
+func parseFilterList(output string) map[string]bool {
+	names := map[string]bool{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && strings.Contains(fields[2], "->") {
+			names[fields[1]] = true
+		}
+	}
+	return names
+}
+
+// parseFormatList parses the output of -formats
+// after the " --" separator each line is "<D/E flags> <name[,alias...]> <description>"
+func parseFormatList(output string) map[string]bool {
+	names := map[string]bool{}
+	inList := false
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if !inList {
+			inList = fields[0] == "--"
+			continue
+		}
+		if len(fields) >= 2 {
+			for _, name := range strings.Split(fields[1], ",") {
+				names[name] = true
+			}
+		}
+	}
+	return names
+}
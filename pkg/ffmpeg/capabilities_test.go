@@ -0,0 +1,98 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ahmadbasyouni10/videogpt/pkg/ffmpeg"
+	"github.com/ahmadbasyouni10/videogpt/pkg/ffmpeg/ffmpegtest"
+)
+
+// trimmed -encoders, -filters and -formats output from an ffmpeg 6.1 build without libmp3lame
+const (
+	encodersOutput = `Encoders:
+ V..... = Video
+ A..... = Audio
+ ------
+ V....D mjpeg                MJPEG (Motion JPEG)
+ A....D aac                  AAC (Advanced Audio Coding)
+`
+	filtersOutput = `Filters:
+  T.. = Timeline support
+  ... silencedetect     A->N       Detect silence.
+  ... anullsrc          |->A       Null audio source, return empty audio frames.
+`
+	formatsOutput = `File formats:
+ D. = Demuxing supported
+ .E = Muxing supported
+ --
+ DE mov,mp4,m4a,3gp,3g2,mj2 QuickTime / MOV
+ DE mp3             MP3 (MPEG audio layer 3)
+`
+)
+
+func TestDetectCapabilities(t *testing.T) {
+	exit := errors.New("exit status 1")
+
+	tests := []struct {
+		name         string
+		responses    []ffmpegtest.Response // ffmpeg -version, ffprobe -version, -encoders, -filters, -formats
+		wantEncoders map[string]bool
+		wantWarnings []string
+		wantMP3      bool
+	}{
+		{
+			name: "full probe",
+			responses: []ffmpegtest.Response{
+				{Output: "ffmpeg version 6.1.1 Copyright (c) 2000-2023 the FFmpeg developers\n"},
+				{Output: "ffprobe version 6.1.1 Copyright (c) 2007-2023 the FFmpeg developers\n"},
+				{Output: encodersOutput},
+				{Output: filtersOutput},
+				{Output: formatsOutput},
+			},
+			wantEncoders: map[string]bool{"mjpeg": true, "aac": true},
+			wantWarnings: []string{"libmp3lame not available, audio will be extracted as AAC"},
+			wantMP3:      false,
+		},
+		{
+			name: "encoders can't be listed",
+			responses: []ffmpegtest.Response{
+				{Output: "ffmpeg version 6.1.1 Copyright (c) 2000-2023 the FFmpeg developers\n"},
+				{Output: "ffprobe version 6.1.1 Copyright (c) 2007-2023 the FFmpeg developers\n"},
+				{Output: "Unrecognized option 'encoders'.\n", Err: exit},
+				{Output: filtersOutput},
+				{Output: formatsOutput},
+			},
+			wantEncoders: nil,
+			wantWarnings: []string{"failed to list encoders: ffmpeg: Unrecognized option 'encoders'.: exit status 1"},
+			wantMP3:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caps, err := ffmpeg.DetectCapabilities(context.Background(), ffmpegtest.NewRunner(tt.responses...))
+			if err != nil {
+				t.Fatalf("DetectCapabilities: %v", err)
+			}
+
+			if caps.FFmpegVersion != "6.1.1" || !caps.HasFFprobe {
+				t.Errorf("ffmpeg %q, ffprobe %v, want 6.1.1 with ffprobe", caps.FFmpegVersion, caps.HasFFprobe)
+			}
+			if !reflect.DeepEqual(caps.Encoders, tt.wantEncoders) {
+				t.Errorf("encoders = %v, want %v", caps.Encoders, tt.wantEncoders)
+			}
+			if !reflect.DeepEqual(caps.Warnings, tt.wantWarnings) {
+				t.Errorf("warnings = %q, want %q", caps.Warnings, tt.wantWarnings)
+			}
+			if got := caps.HasEncoder("libmp3lame"); got != tt.wantMP3 {
+				t.Errorf("HasEncoder(libmp3lame) = %v, want %v", got, tt.wantMP3)
+			}
+			if !caps.HasFilter("silencedetect") || !caps.HasFormat("mp4") {
+				t.Errorf("filters %v and formats %v are missing silencedetect or mp4", caps.Filters, caps.Formats)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Chapter is one chapter marker to embed, times in seconds
+type Chapter struct {
+	Start float64
+	End   float64
+	Title string
+}
+
+// metadataEscaper escapes the characters FFMETADATA files treat specially
+var metadataEscaper = strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", `\`+"\n")
+
+// chapterMetadata renders chapters as an FFMETADATA file, with millisecond timestamps
+func chapterMetadata(chapters []Chapter) string {
+	var b strings.Builder
+	b.WriteString(";FFMETADATA1\n")
+	for _, chapter := range chapters {
+		b.WriteString("\n[CHAPTER]\nTIMEBASE=1/1000\n")
+		fmt.Fprintf(&b, "START=%d\n", int64(chapter.Start*1000))
+		fmt.Fprintf(&b, "END=%d\n", int64(chapter.End*1000))
+		fmt.Fprintf(&b, "title=%s\n", metadataEscaper.Replace(chapter.Title))
+	}
+	return b.String()
+}
+
+// EmbedChapters writes a copy of the video with the chapters as container metadata,
+// which players like VLC and most browsers' media UIs show as a chapter list
+// streams are copied rather than re-encoded, so this is quick even for long videos
+// the caller is responsible for removing the returned file
+func (p *Processor) EmbedChapters(ctx context.Context, videoPath string, chapters []Chapter) (string, error) {
+	if len(chapters) == 0 {
+		return "", errors.New("no chapters to embed")
+	}
+
+	// temp files with unique names, two requests can embed chapters into the same video at once
+	metadataFile, err := os.CreateTemp(p.TempDir, "chapters-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to write chapter metadata: %w", err)
+	}
+	defer os.Remove(metadataFile.Name())
+	_, err = metadataFile.WriteString(chapterMetadata(chapters))
+	if closeErr := metadataFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write chapter metadata: %w", err)
+	}
+	metadataPath := metadataFile.Name()
+
+	outputFile, err := os.CreateTemp(p.TempDir, "chapters-*"+filepath.Ext(videoPath))
+	if err != nil {
+		return "", fmt.Errorf("failed to create chaptered video: %w", err)
+	}
+	outputFile.Close()
+	outputPath := outputFile.Name()
+
+	_, err = p.Runner.CombinedOutput(ctx,
+		"ffmpeg",
+		"-i", videoPath,
+		"-f", "ffmetadata", "-i", metadataPath,
+		"-map", "0", // Every stream of the original
+		"-map_metadata", "0", // Keep its tags
+		"-map_chapters", "1", // Chapters from the metadata file, replacing any it had
+		"-codec", "copy", // No re-encoding
+		"-y",
+		outputPath,
+	)
+	if err != nil {
+		os.Remove(outputPath)
+		return "", fmt.Errorf("failed to embed chapters: %w", err)
+	}
+
+	return outputPath, nil
+}
@@ -0,0 +1,95 @@
+// Package ffmpegtest provides a fake ffmpeg.Runner so code that uses the
+// Processor can be exercised without ffmpeg installed
+package ffmpegtest
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/ahmadbasyouni10/videogpt/pkg/ffmpeg"
+)
+
+// Call records one command the fake runner was asked to run
+type Call struct {
+	Name     string
+	Args     []string
+	Combined bool // true when called through CombinedOutput
+}
+
+// String returns the call as a shell-like command line
+func (c Call) String() string {
+	return c.Name + " " + strings.Join(c.Args, " ")
+}
+
+// Response is the scripted result for one call
+type Response struct {
+	Output string
+	Err    error
+}
+
+// Runner is a fake ffmpeg.Runner that records every call and replays scripted responses
+// responses are handed out in order, once they run out the runner returns empty output
+type Runner struct {
+	mu        sync.Mutex
+	Calls     []Call
+	Responses []Response
+}
+
+var _ ffmpeg.Runner = (*Runner)(nil)
+
+// NewRunner creates a fake runner that will answer with the given responses in order
+func NewRunner(responses ...Response) *Runner {
+	return &Runner{Responses: responses}
+}
+
+// Output records the call and returns the next scripted response
+func (r *Runner) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return r.next(ctx, name, args, false)
+}
+
+// CombinedOutput records the call and returns the next scripted response
+func (r *Runner) CombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return r.next(ctx, name, args, true)
+}
+
+func (r *Runner) next(ctx context.Context, name string, args []string, combined bool) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Calls = append(r.Calls, Call{
+		Name:     name,
+		Args:     append([]string(nil), args...),
+		Combined: combined,
+	})
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(r.Responses) == 0 {
+		return nil, nil
+	}
+	resp := r.Responses[0]
+	r.Responses = r.Responses[1:]
+
+	// wrap errors the same way ExecRunner does so error parsing can be exercised
+	if resp.Err != nil {
+		return []byte(resp.Output), &ffmpeg.RunError{Name: name, Args: args, Output: []byte(resp.Output), Err: resp.Err}
+	}
+	return []byte(resp.Output), nil
+}
+
+// CallsTo returns the recorded calls for one binary (e.g. "ffprobe")
+func (r *Runner) CallsTo(name string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var calls []Call
+	for _, call := range r.Calls {
+		if call.Name == name {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
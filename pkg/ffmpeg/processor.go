@@ -1,16 +1,36 @@
 package ffmpeg
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
+	"regexp"
+	"time"
 )
 
+// Whisper downsamples everything to 16 kHz mono, so there is no point extracting more than that
+const (
+	SpeechSampleRate = "16000"
+	SpeechBitrate    = "32k"
+)
+
+// ErrNoAudio is returned by ExtractAudio when the video has no audio stream
+var ErrNoAudio = errors.New("video has no audio stream")
+
+// ErrMissingEncoder is returned when the ffmpeg build lacks an encoder a feature needs
+var ErrMissingEncoder = errors.New("required encoder not available in this ffmpeg build")
+
 // this processor will process with ffmpeg and the temp directory will be used to store the video and audio files
+// Runner is what actually runs ffmpeg/ffprobe so it can be faked in tests
+// Capabilities is nil until DetectCapabilities runs, and nil means "assume everything is there"
 type Processor struct {
-	TempDir string
+	TempDir      string
+	Runner       Runner
+	Capabilities *Capabilities
 }
 
 // this is a constructor for the Processor struct
@@ -35,90 +55,129 @@ func NewProcessor(tempDir string) (*Processor, error) {
 		return nil, fmt.Errorf("ffmpeg not found in path: %w", err)
 	}
 
+	processor := NewProcessorWithRunner(tempDir, ExecRunner{})
+
+	// probe what this ffmpeg build supports so features can degrade up front
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := processor.DetectCapabilities(ctx); err != nil {
+		return nil, err
+	}
+	for _, warning := range processor.Capabilities.Warnings {
+		log.Printf("Warning: ffmpeg: %s", warning)
+	}
+
+	return processor, nil
+}
+
+// NewProcessorWithRunner creates a Processor that runs commands through the given runner
+// it skips the PATH and temp directory checks so it can be used with a fake runner
+func NewProcessorWithRunner(tempDir string, runner Runner) *Processor {
 	return &Processor{
 		TempDir: tempDir,
-	}, nil
+		Runner:  runner,
+	}
+}
+
+// DetectCapabilities probes the ffmpeg build and stores the result on the processor
+func (p *Processor) DetectCapabilities(ctx context.Context) error {
+	caps, err := DetectCapabilities(ctx, p.Runner)
+	if err != nil {
+		return err
+	}
+	p.Capabilities = caps
+	return nil
+}
+
+// AudioCodec picks the codec and file extension for extracted audio
+// mp3 needs libmp3lame, the native aac encoder is always built in so it is the fallback
+func (p *Processor) AudioCodec() (codec string, ext string) {
+	if p.Capabilities.HasEncoder("libmp3lame") {
+		return "mp3", ".mp3"
+	}
+	return "aac", ".m4a"
+}
+
+// CanCreateThumbnails reports whether CreateThumbnail will work, jpeg thumbnails need the mjpeg encoder
+func (p *Processor) CanCreateThumbnails() bool {
+	return p.Capabilities.HasEncoder("mjpeg")
+}
+
+// DurationProbe names the tool GetVideoDuration reads durations with
+func (p *Processor) DurationProbe() string {
+	if p.Capabilities.CanProbe() {
+		return "ffprobe"
+	}
+	return "ffmpeg"
+}
+
+// HasAudio checks if the video has at least one audio stream
+func (p *Processor) HasAudio(ctx context.Context, videoPath string) bool {
+	tracks, err := p.ListAudioTracks(ctx, videoPath)
+	return err == nil && len(tracks) > 0
 }
 
 // this function will extract audio
 // it is a method on the Processor struct
 // like making methods for a class using self.
-func (p *Processor) ExtractAudio(videoPath string) (string, error) {
+// the audio is 16 kHz mono at a low bitrate since that is all Whisper uses,
+// which keeps an hour of speech around 14 MB instead of blowing past the upload limit
+func (p *Processor) ExtractAudio(ctx context.Context, videoPath string) (string, error) {
+	return p.ExtractAudioTrack(ctx, videoPath, -1)
+}
+
+// ExtractAudioTrack extracts one audio track (an AudioTrack.Index) from the video
+// a negative track lets ffmpeg pick the default stream like ExtractAudio does
+func (p *Processor) ExtractAudioTrack(ctx context.Context, videoPath string, track int) (string, error) {
 	// gets base name of the video without directory extension
 	fileName := filepath.Base(videoPath)
 	fileNameWithoutExt := fileName[:len(fileName)-len(filepath.Ext(fileName))]
-	audioPath := filepath.Join(p.TempDir, fileNameWithoutExt+".mp3")
+	if track >= 0 {
+		// keep tracks from overwriting each other when several are extracted
+		fileNameWithoutExt = fmt.Sprintf("%s_a%d", fileNameWithoutExt, track)
+	}
+	codec, ext := p.AudioCodec()
+	audioPath := filepath.Join(p.TempDir, fileNameWithoutExt+ext)
 
 	// First check if the video has audio streams
-	hasAudioCmd := exec.Command(
-		"ffprobe",
-		"-v", "error",
-		"-select_streams", "a",
-		"-show_entries", "stream=codec_type",
-		"-of", "default=noprint_wrappers=1:nokey=1",
-		videoPath,
-	)
-
-	output, _ := hasAudioCmd.Output()
-	hasAudio := strings.TrimSpace(string(output)) == "audio"
-
-	var cmd *exec.Cmd
-
-	if hasAudio {
-		// If the video has audio, extract it normally
-		cmd = exec.Command(
-			"ffmpeg",
-			"-i", videoPath,
-			"-vn",            // No video
-			"-acodec", "mp3", // Force mp3 codec
-			"-y", // Overwrite output files
-			audioPath,
-		)
-	} else {
-		// If no audio, create a silent audio track with same duration as the video
-		// First get the duration
-		durationCmd := exec.Command(
-			"ffprobe",
-			"-v", "error",
-			"-show_entries", "format=duration",
-			"-of", "default=noprint_wrappers=1:nokey=1",
-			videoPath,
-		)
-
-		durationOutput, err := durationCmd.Output()
-		if err != nil {
-			return "", fmt.Errorf("failed to get video duration: %w", err)
-		}
-
-		duration := strings.TrimSpace(string(durationOutput))
+	// there used to be a silent track synthesized here, but that just paid
+	// Whisper to hallucinate text, so callers now get ErrNoAudio instead
+	if !p.HasAudio(ctx, videoPath) {
+		return "", ErrNoAudio
+	}
 
-		// Create silent audio
-		cmd = exec.Command(
-			"ffmpeg",
-			"-f", "lavfi", // Use libavfilter
-			"-i", "anullsrc=r=44100:cl=stereo", // Generate silent audio
-			"-t", duration, // Same duration as video
-			"-acodec", "mp3", // MP3 codec
-			"-y", // Overwrite output
-			audioPath,
-		)
+	args := []string{"-i", videoPath}
+	if track >= 0 {
+		args = append(args, "-map", fmt.Sprintf("0:a:%d", track)) // Only the requested audio track
 	}
+	args = append(args,
+		"-vn",            // No video
+		"-acodec", codec, // mp3, or aac when libmp3lame is missing
+		"-ac", "1", // Mono
+		"-ar", SpeechSampleRate, // 16 kHz is what Whisper resamples to anyway
+		"-b:a", SpeechBitrate, // Low bitrate is plenty for speech
+		"-y", // Overwrite output files
+		audioPath,
+	)
 
 	// Run the command
-	cmdOutput, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("failed to extract audio: %s - %w", string(cmdOutput), err)
+	if _, err := p.Runner.CombinedOutput(ctx, "ffmpeg", args...); err != nil {
+		return "", fmt.Errorf("failed to extract audio: %w", err)
 	}
 
 	return audioPath, nil
 }
 
-func (p *Processor) CreateThumbnail(videoPath string) (string, error) {
+func (p *Processor) CreateThumbnail(ctx context.Context, videoPath string) (string, error) {
 	fileName := filepath.Base(videoPath)
 	fileNameWithoutExt := fileName[:len(fileName)-len(filepath.Ext(fileName))]
 	thumbnailPath := filepath.Join(p.TempDir, fileNameWithoutExt+".jpg")
 
-	cmd := exec.Command(
+	if !p.CanCreateThumbnails() {
+		return "", fmt.Errorf("failed to create thumbnail: mjpeg: %w", ErrMissingEncoder)
+	}
+
+	_, err := p.Runner.CombinedOutput(ctx,
 		"ffmpeg",
 		"-i", videoPath,
 		"-ss", "00:00:01",
@@ -126,24 +185,25 @@ func (p *Processor) CreateThumbnail(videoPath string) (string, error) {
 		"-y",
 		thumbnailPath,
 	)
-
-	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return "", fmt.Errorf("failed to create thumbnail: %s - %w", string(output), err)
+		return "", fmt.Errorf("failed to create thumbnail: %w", err)
 	}
 
 	return thumbnailPath, nil
 }
 
-func (p *Processor) GetVideoDuration(videoPath string) (float64, error) {
-	cmd := exec.Command(
+func (p *Processor) GetVideoDuration(ctx context.Context, videoPath string) (float64, error) {
+	if !p.Capabilities.CanProbe() {
+		return p.durationFromFFmpeg(ctx, videoPath)
+	}
+
+	output, err := p.Runner.Output(ctx,
 		"ffprobe",
 		"-v", "error",
 		"-show_entries", "format=duration",
 		"-of", "default=noprint_wrappers=1:nokey=1",
 		videoPath,
 	)
-	output, err := cmd.Output()
 	if err != nil {
 		return 0, fmt.Errorf("failed to get video duration: %w", err)
 	}
@@ -160,3 +220,25 @@ func (p *Processor) GetVideoDuration(videoPath string) (float64, error) {
 
 	return duration, nil
 }
+
+// durationPattern matches the "Duration: 00:01:02.03" line ffmpeg prints for each input
+var durationPattern = regexp.MustCompile(`Duration: (\d+):(\d+):(\d+(?:\.\d+)?)`)
+
+// durationFromFFmpeg reads the duration from ffmpeg -i output when ffprobe is missing
+func (p *Processor) durationFromFFmpeg(ctx context.Context, videoPath string) (float64, error) {
+	// ffmpeg -i with no output always exits with an error, we only want the header
+	output, _ := p.Runner.CombinedOutput(ctx, "ffmpeg", "-hide_banner", "-i", videoPath)
+
+	match := durationPattern.FindStringSubmatch(string(output))
+	if match == nil {
+		return 0, fmt.Errorf("failed to get video duration: %s", ParseErrorOutput(string(output)))
+	}
+
+	var hours, minutes int
+	var seconds float64
+	fmt.Sscanf(match[1], "%d", &hours)
+	fmt.Sscanf(match[2], "%d", &minutes)
+	fmt.Sscanf(match[3], "%f", &seconds)
+
+	return float64(hours*3600+minutes*60) + seconds, nil
+}
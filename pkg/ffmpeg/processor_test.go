@@ -0,0 +1,207 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ahmadbasyouni10/videogpt/pkg/ffmpeg"
+	"github.com/ahmadbasyouni10/videogpt/pkg/ffmpeg/ffmpegtest"
+)
+
+// oneTrack is ffprobe's answer for a video with a single english audio stream
+const oneTrack = `{"streams": [{"index": 1, "codec_name": "aac", "channels": 2, "tags": {"language": "eng"}, "disposition": {"default": 1}}]}`
+
+func TestExtractAudio(t *testing.T) {
+	tests := []struct {
+		name      string
+		caps      *ffmpeg.Capabilities
+		responses []ffmpegtest.Response
+		wantCalls []string // the binaries run, in order
+		wantArgs  string   // fragment of the ffmpeg command
+		wantPath  string
+	}{
+		{
+			name:      "video with audio",
+			responses: []ffmpegtest.Response{{Output: oneTrack}},
+			wantCalls: []string{"ffprobe", "ffmpeg"},
+			wantArgs:  "-i /videos/talk.mp4 -vn -acodec mp3 -ac 1 -ar 16000 -b:a 32k",
+			wantPath:  "talk.mp3",
+		},
+		{
+			name:      "aac when libmp3lame is missing",
+			caps:      &ffmpeg.Capabilities{HasFFprobe: true, Encoders: map[string]bool{"aac": true}},
+			responses: []ffmpegtest.Response{{Output: oneTrack}},
+			wantCalls: []string{"ffprobe", "ffmpeg"},
+			wantArgs:  "-vn -acodec aac",
+			wantPath:  "talk.m4a",
+		},
+		{
+			name:      "ffmpeg -i finds the audio without ffprobe",
+			caps:      &ffmpeg.Capabilities{Encoders: map[string]bool{"libmp3lame": true}},
+			responses: []ffmpegtest.Response{{Output: "  Stream #0:1(eng): Audio: aac (LC), 48000 Hz, stereo\n"}},
+			wantCalls: []string{"ffmpeg", "ffmpeg"},
+			wantArgs:  "-vn -acodec mp3",
+			wantPath:  "talk.mp3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			runner := ffmpegtest.NewRunner(tt.responses...)
+			p := ffmpeg.NewProcessorWithRunner(tempDir, runner)
+			p.Capabilities = tt.caps
+
+			audioPath, err := p.ExtractAudio(context.Background(), "/videos/talk.mp4")
+			if err != nil {
+				t.Fatalf("ExtractAudio: %v", err)
+			}
+			if want := filepath.Join(tempDir, tt.wantPath); audioPath != want {
+				t.Errorf("path = %q, want %q", audioPath, want)
+			}
+
+			var names []string
+			for _, call := range runner.Calls {
+				names = append(names, call.Name)
+			}
+			if strings.Join(names, " ") != strings.Join(tt.wantCalls, " ") {
+				t.Fatalf("ran %v, want %v", names, tt.wantCalls)
+			}
+			if cmd := runner.Calls[len(runner.Calls)-1].String(); !strings.Contains(cmd, tt.wantArgs) {
+				t.Errorf("command %q does not contain %q", cmd, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestExtractAudioNoAudio(t *testing.T) {
+	runner := ffmpegtest.NewRunner(ffmpegtest.Response{Output: `{"streams": []}`})
+	p := ffmpeg.NewProcessorWithRunner(t.TempDir(), runner)
+
+	if _, err := p.ExtractAudio(context.Background(), "talk.mp4"); !errors.Is(err, ffmpeg.ErrNoAudio) {
+		t.Fatalf("err = %v, want ErrNoAudio", err)
+	}
+	// nothing should be sent to ffmpeg for a video without audio
+	if calls := runner.CallsTo("ffmpeg"); len(calls) != 0 {
+		t.Errorf("ran ffmpeg %d times, want none", len(calls))
+	}
+}
+
+func TestExtractAudioTrackMapsTrack(t *testing.T) {
+	runner := ffmpegtest.NewRunner(ffmpegtest.Response{Output: oneTrack})
+	p := ffmpeg.NewProcessorWithRunner(t.TempDir(), runner)
+
+	audioPath, err := p.ExtractAudioTrack(context.Background(), "talk.mp4", 1)
+	if err != nil {
+		t.Fatalf("ExtractAudioTrack: %v", err)
+	}
+	if filepath.Base(audioPath) != "talk_a1.mp3" {
+		t.Errorf("path = %q, want talk_a1.mp3", audioPath)
+	}
+	if cmd := runner.CallsTo("ffmpeg")[0].String(); !strings.Contains(cmd, "-map 0:a:1") {
+		t.Errorf("command %q does not map track 1", cmd)
+	}
+}
+
+func TestExtractAudioFailure(t *testing.T) {
+	exit := errors.New("exit status 1")
+	runner := ffmpegtest.NewRunner(
+		ffmpegtest.Response{Output: oneTrack},
+		ffmpegtest.Response{
+			Output: "ffmpeg version 6.0\nInput #0, mov,mp4\ntalk.mp4: Invalid data found when processing input\n",
+			Err:    exit,
+		},
+	)
+	p := ffmpeg.NewProcessorWithRunner(t.TempDir(), runner)
+
+	_, err := p.ExtractAudio(context.Background(), "talk.mp4")
+	var runErr *ffmpeg.RunError
+	if !errors.As(err, &runErr) || !errors.Is(err, exit) {
+		t.Fatalf("err = %v, want a RunError wrapping the exit error", err)
+	}
+	if want := "failed to extract audio: ffmpeg: talk.mp4: Invalid data found when processing input: exit status 1"; err.Error() != want {
+		t.Errorf("err = %q, want %q", err, want)
+	}
+}
+
+func TestGetVideoDuration(t *testing.T) {
+	// without ffprobe the duration comes from the header ffmpeg -i prints
+	noProbe := &ffmpeg.Capabilities{}
+
+	tests := []struct {
+		name     string
+		caps     *ffmpeg.Capabilities
+		response ffmpegtest.Response
+		want     float64
+		wantErr  bool
+	}{
+		{name: "duration", response: ffmpegtest.Response{Output: "62.5\n"}, want: 62.5},
+		{name: "not a number", response: ffmpegtest.Response{Output: "N/A\n"}, wantErr: true},
+		{name: "ffprobe fails", response: ffmpegtest.Response{Err: errors.New("exit status 1")}, wantErr: true},
+		{
+			name:     "ffmpeg header",
+			caps:     noProbe,
+			response: ffmpegtest.Response{Output: "Input #0, mov,mp4\n  Duration: 01:02:03.50, start: 0.000000, bitrate: 128 kb/s\n", Err: errors.New("exit status 1")},
+			want:     3723.5,
+		},
+		{
+			name:     "ffmpeg header without a duration",
+			caps:     noProbe,
+			response: ffmpegtest.Response{Output: "talk.mp4: No such file or directory\n", Err: errors.New("exit status 1")},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := ffmpegtest.NewRunner(tt.response)
+			p := ffmpeg.NewProcessorWithRunner(t.TempDir(), runner)
+			p.Capabilities = tt.caps
+
+			got, err := p.GetVideoDuration(context.Background(), "talk.mp4")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("duration = %v, want %v", got, tt.want)
+			}
+			// diagnostics reports DurationProbe, so it has to name what actually ran
+			if probe := runner.Calls[0].Name; probe != p.DurationProbe() {
+				t.Errorf("probed with %s, want %s", probe, p.DurationProbe())
+			}
+		})
+	}
+}
+
+func TestCreateThumbnail(t *testing.T) {
+	tempDir := t.TempDir()
+	runner := ffmpegtest.NewRunner()
+	p := ffmpeg.NewProcessorWithRunner(tempDir, runner)
+
+	thumbnailPath, err := p.CreateThumbnail(context.Background(), "/videos/talk.mp4")
+	if err != nil {
+		t.Fatalf("CreateThumbnail: %v", err)
+	}
+	if want := filepath.Join(tempDir, "talk.jpg"); thumbnailPath != want {
+		t.Errorf("path = %q, want %q", thumbnailPath, want)
+	}
+	if want := "ffmpeg -i /videos/talk.mp4 -ss 00:00:01 -vframes 1 -y " + thumbnailPath; runner.Calls[0].String() != want {
+		t.Errorf("command = %q, want %q", runner.Calls[0].String(), want)
+	}
+}
+
+func TestCreateThumbnailMissingEncoder(t *testing.T) {
+	runner := ffmpegtest.NewRunner()
+	p := ffmpeg.NewProcessorWithRunner(t.TempDir(), runner)
+	p.Capabilities = &ffmpeg.Capabilities{Encoders: map[string]bool{"aac": true}}
+
+	if _, err := p.CreateThumbnail(context.Background(), "talk.mp4"); !errors.Is(err, ffmpeg.ErrMissingEncoder) {
+		t.Fatalf("err = %v, want ErrMissingEncoder", err)
+	}
+	if len(runner.Calls) != 0 {
+		t.Errorf("ran %d commands, want none", len(runner.Calls))
+	}
+}
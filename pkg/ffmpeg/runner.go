@@ -0,0 +1,103 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Runner runs the external ffmpeg/ffprobe binaries for the Processor
+// the default ExecRunner shells out with os/exec, tests can swap in a fake
+// that records the argv and returns scripted output (see ffmpegtest)
+type Runner interface {
+	// Output runs the command and returns only its standard output
+	Output(ctx context.Context, name string, args ...string) ([]byte, error)
+	// CombinedOutput runs the command and returns stdout and stderr together
+	CombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// ExecRunner implements Runner using os/exec
+type ExecRunner struct{}
+
+// Output runs the command and returns its standard output
+func (ExecRunner) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	output, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		// Output only collects stderr on the exit error, so pull it from there
+		var stderr []byte
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			stderr = exitErr.Stderr
+		}
+		return output, &RunError{Name: name, Args: args, Output: stderr, Err: err}
+	}
+	return output, nil
+}
+
+// CombinedOutput runs the command and returns stdout and stderr together
+func (ExecRunner) CombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	output, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		return output, &RunError{Name: name, Args: args, Output: output, Err: err}
+	}
+	return output, nil
+}
+
+// RunError is returned when ffmpeg or ffprobe exits with an error
+// it keeps the full output around but only shows the line that explains the failure
+type RunError struct {
+	Name   string
+	Args   []string
+	Output []byte
+	Err    error
+}
+
+func (e *RunError) Error() string {
+	if reason := ParseErrorOutput(string(e.Output)); reason != "" {
+		return fmt.Sprintf("%s: %s: %v", e.Name, reason, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Name, e.Err)
+}
+
+func (e *RunError) Unwrap() error {
+	return e.Err
+}
+
+// errorMarkers are fragments ffmpeg uses on the lines that actually explain a failure
+var errorMarkers = []string{
+	"No such file or directory",
+	"Invalid data found",
+	"does not contain any stream",
+	"Output file is empty",
+	"Unknown encoder",
+	"Unknown decoder",
+	"Permission denied",
+	"Error ",
+	"error ",
+	"Invalid ",
+}
+
+// ParseErrorOutput picks the most useful line out of ffmpeg's output
+// ffmpeg prints a banner and stream info before the real error, so we look for a
+// line with a known error marker and fall back to the last non-empty line
+func ParseErrorOutput(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		for _, marker := range errorMarkers {
+			if strings.Contains(line, marker) {
+				return line
+			}
+		}
+	}
+
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			return line
+		}
+	}
+	return ""
+}
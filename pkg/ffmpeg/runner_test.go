@@ -0,0 +1,84 @@
+package ffmpeg_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ahmadbasyouni10/videogpt/pkg/ffmpeg"
+)
+
+func TestParseErrorOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{
+			name:   "empty",
+			output: "",
+			want:   "",
+		},
+		{
+			name: "error line after the banner",
+			output: "ffmpeg version 6.0 Copyright (c) 2000-2023\n" +
+				"  built with gcc 12\n" +
+				"missing.mp4: No such file or directory\n",
+			want: "missing.mp4: No such file or directory",
+		},
+		{
+			name: "first marker wins",
+			output: "Unknown encoder 'libmp3lame'\n" +
+				"Error opening output files: Encoder not found\n",
+			want: "Unknown encoder 'libmp3lame'",
+		},
+		{
+			name:   "last line when nothing matches",
+			output: "ffmpeg version 6.0\nsomething went sideways\n\n",
+			want:   "something went sideways",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ffmpeg.ParseErrorOutput(tt.output); got != tt.want {
+				t.Errorf("ParseErrorOutput = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunError(t *testing.T) {
+	exit := errors.New("exit status 1")
+
+	tests := []struct {
+		name string
+		err  *ffmpeg.RunError
+		want string
+	}{
+		{
+			name: "with output",
+			err: &ffmpeg.RunError{
+				Name:   "ffmpeg",
+				Output: []byte("ffmpeg version 6.0\ntalk.mp4: Invalid data found when processing input\n"),
+				Err:    exit,
+			},
+			want: "ffmpeg: talk.mp4: Invalid data found when processing input: exit status 1",
+		},
+		{
+			name: "without output",
+			err:  &ffmpeg.RunError{Name: "ffprobe", Err: exit},
+			want: "ffprobe: exit status 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error = %q, want %q", got, tt.want)
+			}
+			if !errors.Is(tt.err, exit) {
+				t.Error("RunError does not unwrap to the exit error")
+			}
+		})
+	}
+}
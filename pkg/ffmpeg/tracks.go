@@ -0,0 +1,141 @@
+package ffmpeg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// AudioTrack describes one audio stream in a video
+// Index counts audio streams only (what -map 0:a:N expects), StreamIndex is the container index
+type AudioTrack struct {
+	Index       int    `json:"index"`
+	StreamIndex int    `json:"stream_index"`
+	Codec       string `json:"codec,omitempty"`
+	Channels    int    `json:"channels,omitempty"`
+	Language    string `json:"language,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Default     bool   `json:"default"`
+}
+
+// ffprobeStreams is the part of ffprobe -of json output we care about
+type ffprobeStreams struct {
+	Streams []struct {
+		Index       int               `json:"index"`
+		CodecName   string            `json:"codec_name"`
+		Channels    int               `json:"channels"`
+		Tags        map[string]string `json:"tags"`
+		Disposition map[string]int    `json:"disposition"`
+	} `json:"streams"`
+}
+
+// ListAudioTracks returns every audio stream in the file with its language tag
+func (p *Processor) ListAudioTracks(ctx context.Context, videoPath string) ([]AudioTrack, error) {
+	if !p.Capabilities.CanProbe() {
+		return p.audioTracksFromFFmpeg(ctx, videoPath)
+	}
+
+	output, err := p.Runner.Output(ctx,
+		"ffprobe",
+		"-v", "error",
+		"-select_streams", "a",
+		"-show_entries", "stream=index,codec_name,channels:stream_tags=language,title:stream_disposition=default",
+		"-of", "json",
+		videoPath,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audio tracks: %w", err)
+	}
+
+	var probe ffprobeStreams
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse audio tracks: %w", err)
+	}
+
+	tracks := make([]AudioTrack, 0, len(probe.Streams))
+	for i, stream := range probe.Streams {
+		tracks = append(tracks, AudioTrack{
+			Index:       i,
+			StreamIndex: stream.Index,
+			Codec:       stream.CodecName,
+			Channels:    stream.Channels,
+			Language:    stream.Tags["language"],
+			Title:       stream.Tags["title"],
+			Default:     stream.Disposition["default"] == 1,
+		})
+	}
+	return tracks, nil
+}
+
+// audioStreamPattern matches "Stream #0:1(eng): Audio: aac (LC) ..." lines from ffmpeg -i
+var audioStreamPattern = regexp.MustCompile(`Stream #\d+:(\d+)(?:\[\w+\])?(?:\((\w+)\))?: Audio: (\w+)([^\n]*)`)
+
+// audioTracksFromFFmpeg reads audio streams from ffmpeg -i output when ffprobe is missing
+func (p *Processor) audioTracksFromFFmpeg(ctx context.Context, videoPath string) ([]AudioTrack, error) {
+	// ffmpeg -i with no output always exits with an error, we only want the stream info
+	output, _ := p.Runner.CombinedOutput(ctx, "ffmpeg", "-hide_banner", "-i", videoPath)
+
+	var tracks []AudioTrack
+	for i, match := range audioStreamPattern.FindAllStringSubmatch(string(output), -1) {
+		track := AudioTrack{
+			Index:    i,
+			Codec:    match[3],
+			Language: match[2],
+			Default:  strings.Contains(match[4], "(default)"),
+		}
+		fmt.Sscanf(match[1], "%d", &track.StreamIndex)
+		tracks = append(tracks, track)
+	}
+	return tracks, nil
+}
+
+// languageCodes maps the ISO 639-1 codes people pass in to the ISO 639-2 tags containers use
+var languageCodes = map[string]string{
+	"ar": "ara", "de": "deu", "en": "eng", "es": "spa", "fr": "fra",
+	"hi": "hin", "it": "ita", "ja": "jpn", "ko": "kor", "nl": "nld",
+	"pl": "pol", "pt": "por", "ru": "rus", "sv": "swe", "tr": "tur",
+	"uk": "ukr", "zh": "zho",
+}
+
+// alternateLanguageCodes covers the bibliographic 639-2 codes some muxers write instead
+var alternateLanguageCodes = map[string]string{
+	"ger": "deu", "fre": "fra", "dut": "nld", "chi": "zho",
+}
+
+// normalizeLanguage turns "en", "eng" and "EN" into the same 3-letter tag
+func normalizeLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if code, ok := languageCodes[language]; ok {
+		return code
+	}
+	if code, ok := alternateLanguageCodes[language]; ok {
+		return code
+	}
+	return language
+}
+
+// SelectAudioTrack picks the track to transcribe
+// a matching language wins, then the track flagged as default, then the first one
+func SelectAudioTrack(tracks []AudioTrack, language string) (AudioTrack, bool) {
+	if len(tracks) == 0 {
+		return AudioTrack{}, false
+	}
+
+	if language != "" {
+		want := normalizeLanguage(language)
+		for _, track := range tracks {
+			if normalizeLanguage(track.Language) == want {
+				return track, true
+			}
+		}
+	}
+
+	for _, track := range tracks {
+		if track.Default {
+			return track, true
+		}
+	}
+	return tracks[0], true
+}
@@ -0,0 +1,94 @@
+// Package glossary applies a workspace's custom vocabulary to transcription
+package glossary
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+)
+
+// Glossary is the set of entries for one workspace
+type Glossary []models.GlossaryEntry
+
+// Terms returns the preferred spellings, for transcription.Options.Vocabulary
+// entries that don't fit in Whisper's prompt are left to the find-and-replace pass
+func (g Glossary) Terms() []string {
+	var terms []string
+	for _, entry := range g {
+		if spelling := strings.TrimSpace(entry.Spelling()); spelling != "" {
+			terms = append(terms, spelling)
+		}
+	}
+	return terms
+}
+
+// replacement is one compiled find-and-replace rule
+type replacement struct {
+	pattern  *regexp.Regexp
+	spelling string
+}
+
+// replacements compiles a case-insensitive whole-word rule for each term and alias
+func (g Glossary) replacements() []replacement {
+	var rules []replacement
+	for _, entry := range g {
+		spelling := entry.Spelling()
+		if spelling == "" {
+			continue
+		}
+		variants := append([]string{entry.Term}, entry.Aliases...)
+		for _, variant := range variants {
+			variant = strings.TrimSpace(variant)
+			if variant == "" {
+				continue
+			}
+			// \b only works next to word characters, so only use it on those ends
+			pattern := regexp.QuoteMeta(variant)
+			if isWordChar(variant[0]) {
+				pattern = `\b` + pattern
+			}
+			if isWordChar(variant[len(variant)-1]) {
+				pattern += `\b`
+			}
+			rules = append(rules, replacement{
+				pattern:  regexp.MustCompile(`(?i)` + pattern),
+				spelling: spelling,
+			})
+		}
+	}
+	return rules
+}
+
+func isWordChar(b byte) bool {
+	return b == '_' || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
+
+// ApplyText rewrites aliases and wrong casings of the terms to the preferred spelling
+func (g Glossary) ApplyText(text string) string {
+	return applyRules(g.replacements(), text)
+}
+
+func applyRules(rules []replacement, text string) string {
+	for _, rule := range rules {
+		text = rule.pattern.ReplaceAllLiteralString(text, rule.spelling)
+	}
+	return text
+}
+
+// Apply runs the find-and-replace pass over the transcript text, segments and words in place
+// multi-word aliases can't be fixed on single words, those only change in the text and segments
+func (g Glossary) Apply(transcript *models.Transcript) {
+	rules := g.replacements()
+	if len(rules) == 0 {
+		return
+	}
+
+	transcript.Text = applyRules(rules, transcript.Text)
+	for i := range transcript.Segments {
+		transcript.Segments[i].Text = applyRules(rules, transcript.Segments[i].Text)
+	}
+	for i := range transcript.Words {
+		transcript.Words[i].Word = applyRules(rules, transcript.Words[i].Word)
+	}
+}
@@ -0,0 +1,87 @@
+package glossary
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+)
+
+func TestApplyText(t *testing.T) {
+	g := Glossary{
+		{Term: "Kubernetes", Aliases: []string{"cooper netties", "k8"}},
+		{Term: "postgres", PreferredSpelling: "PostgreSQL"},
+		{Term: "C++"},
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "alias",
+			text: "we deploy on cooper netties",
+			want: "we deploy on Kubernetes",
+		},
+		{
+			name: "wrong casing of the term",
+			text: "KUBERNETES and Postgres",
+			want: "Kubernetes and PostgreSQL",
+		},
+		{
+			name: "whole words only",
+			text: "k8s is not k8",
+			want: "k8s is not Kubernetes",
+		},
+		{
+			name: "term ending in punctuation",
+			text: "written in c++, mostly",
+			want: "written in C++, mostly",
+		},
+		{
+			name: "nothing to fix",
+			text: "hello world",
+			want: "hello world",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := g.ApplyText(tt.text); got != tt.want {
+				t.Errorf("ApplyText(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	g := Glossary{{Term: "gRPC", Aliases: []string{"g rpc"}}}
+	transcript := &models.Transcript{
+		Text:     "we use g rpc and grpc",
+		Segments: []models.Segment{{Text: "we use g rpc and grpc"}},
+		Words:    []models.Word{{Word: " g"}, {Word: " rpc"}, {Word: " grpc"}},
+	}
+
+	g.Apply(transcript)
+
+	if want := "we use gRPC and gRPC"; transcript.Text != want || transcript.Segments[0].Text != want {
+		t.Errorf("text = %q, segment = %q, want %q", transcript.Text, transcript.Segments[0].Text, want)
+	}
+	// the two-word alias can't be fixed one word at a time
+	words := []string{transcript.Words[0].Word, transcript.Words[1].Word, transcript.Words[2].Word}
+	if want := []string{" g", " rpc", " gRPC"}; !reflect.DeepEqual(words, want) {
+		t.Errorf("words = %q, want %q", words, want)
+	}
+}
+
+func TestTerms(t *testing.T) {
+	g := Glossary{
+		{Term: "Kubernetes"},
+		{Term: "postgres", PreferredSpelling: "PostgreSQL"},
+		{Term: "  "},
+	}
+	if want := []string{"Kubernetes", "PostgreSQL"}; !reflect.DeepEqual(g.Terms(), want) {
+		t.Errorf("Terms = %q, want %q", g.Terms(), want)
+	}
+}
@@ -0,0 +1,249 @@
+// Package httpclient is the HTTP layer shared by the AI provider clients
+// it retries the failures that are worth retrying (rate limits, 5xx, dropped connections)
+// with jittered exponential backoff, honours Retry-After and the rate-limit headers,
+// and turns error responses into typed errors callers can check with errors.Is
+package httpclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmadbasyouni10/videogpt/pkg/ratelimit"
+)
+
+// Kinds of API failure, an *Error wraps one of these
+var (
+	ErrRateLimited  = errors.New("rate limited")
+	ErrUnauthorized = errors.New("authentication failed")
+	ErrInvalidInput = errors.New("invalid input")
+	ErrServer       = errors.New("provider server error")
+)
+
+// Error is a non-2xx response from a provider
+type Error struct {
+	StatusCode int
+	Message    string        // the provider's own error message when it sent one
+	RetryAfter time.Duration // how long the provider asked us to wait, zero if it didn't say
+	Err        error         // one of the Err* kinds, nil for statuses we don't classify
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("API request failed with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Client sends requests with retries, it is safe to share between goroutines
+type Client struct {
+	Timeout       time.Duration // per attempt
+	MaxRetries    int
+	BaseDelay     time.Duration // first backoff, doubled on every retry
+	MaxDelay      time.Duration // backoff cap
+	MaxRetryAfter time.Duration // give up rather than wait longer than this for a Retry-After
+	// Limiter queues attempts to stay inside the API key's quotas, nil for no limit
+	Limiter *ratelimit.Limiter
+}
+
+// New creates a client with the given per-attempt timeout and 3 retries
+func New(timeout time.Duration) *Client {
+	return &Client{
+		Timeout:       timeout,
+		MaxRetries:    3,
+		BaseDelay:     500 * time.Millisecond,
+		MaxDelay:      20 * time.Second,
+		MaxRetryAfter: time.Minute,
+	}
+}
+
+// Do sends the request and returns the body of a 2xx response
+// requests with a body need GetBody to be retried, which http.NewRequest sets for
+// bytes.Buffer, bytes.Reader and strings.Reader bodies
+func (c *Client) Do(req *http.Request) ([]byte, error) {
+	return c.DoWeighted(req, 0)
+}
+
+// DoWeighted is Do for calls that count against a tokens-per-minute quota,
+// tokens is the estimated cost of one attempt
+func (c *Client) DoWeighted(req *http.Request, tokens int) ([]byte, error) {
+	ctx := req.Context()
+	client := &http.Client{Timeout: c.Timeout}
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			attemptReq = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, fmt.Errorf("failed to rewind request body: %w", err)
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		// every attempt counts against the quota, retries included
+		release, err := c.Limiter.Acquire(ctx, tokens)
+		if err != nil {
+			return nil, err
+		}
+		body, retryable, err := c.send(client, attemptReq)
+		release()
+		if err == nil {
+			return body, nil
+		}
+
+		canRewind := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+		if !retryable || !canRewind || attempt >= c.MaxRetries || ctx.Err() != nil {
+			return nil, err
+		}
+
+		delay := c.backoff(attempt)
+		var apiErr *Error
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			if apiErr.RetryAfter > c.MaxRetryAfter {
+				return nil, err
+			}
+			delay = apiErr.RetryAfter
+		}
+
+		fmt.Printf("Retrying %s %s in %s (attempt %d of %d): %v\n", req.Method, req.URL.Host, delay.Round(time.Millisecond), attempt+1, c.MaxRetries, err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// send makes one attempt, reporting whether a failure is worth retrying
+func (c *Client) send(client *http.Client, req *http.Request) ([]byte, bool, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		// a cancelled request isn't a network problem, don't retry it
+		if req.Context().Err() != nil {
+			return nil, false, req.Context().Err()
+		}
+		return nil, true, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return body, false, nil
+	}
+
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		Message:    errorMessage(body),
+		RetryAfter: retryAfter(resp.Header),
+	}
+
+	retryable := false
+	switch status := resp.StatusCode; {
+	case status == http.StatusTooManyRequests:
+		apiErr.Err = ErrRateLimited
+		// OpenAI also answers 429 when the account is out of credit, waiting won't fix that
+		retryable = !strings.Contains(string(body), "insufficient_quota")
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		apiErr.Err = ErrUnauthorized
+	case status == http.StatusRequestTimeout:
+		apiErr.Err = ErrServer
+		retryable = true
+	case status >= 400 && status < 500:
+		apiErr.Err = ErrInvalidInput
+	case status == http.StatusNotImplemented:
+		apiErr.Err = ErrServer
+	case status >= 500:
+		apiErr.Err = ErrServer
+		retryable = true
+	}
+	return nil, retryable, apiErr
+}
+
+// backoff returns the jittered delay before retry number attempt+1
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.BaseDelay << attempt
+	if delay <= 0 || delay > c.MaxDelay {
+		delay = c.MaxDelay
+	}
+	// somewhere between half and all of it, so parallel chunks don't retry in lockstep
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// retryAfter reads how long the provider wants us to wait
+// Retry-After and retry-after-ms are standard-ish, OpenAI also reports when its
+// request and token windows reset, which only matters once they're exhausted
+func retryAfter(header http.Header) time.Duration {
+	if value := header.Get("Retry-After-Ms"); value != "" {
+		if ms, err := strconv.ParseFloat(value, 64); err == nil && ms > 0 {
+			return time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+			return time.Duration(seconds * float64(time.Second))
+		}
+		if at, err := http.ParseTime(value); err == nil {
+			return max(time.Until(at), 0)
+		}
+	}
+
+	var wait time.Duration
+	for _, limit := range []string{"requests", "tokens"} {
+		if header.Get("X-Ratelimit-Remaining-"+limit) != "0" {
+			continue
+		}
+		if reset, err := time.ParseDuration(header.Get("X-Ratelimit-Reset-" + limit)); err == nil {
+			wait = max(wait, reset)
+		}
+	}
+	return wait
+}
+
+// errorMessage pulls the human readable message out of an error body
+// providers disagree on the shape, so try the common ones before giving up and using the raw body
+func errorMessage(body []byte) string {
+	var payload struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+		ErrMsg  string          `json:"err_msg"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil {
+		var nested struct {
+			Message string `json:"message"`
+		}
+		var text string
+		switch {
+		case json.Unmarshal(payload.Error, &nested) == nil && nested.Message != "":
+			return nested.Message
+		case json.Unmarshal(payload.Error, &text) == nil && text != "":
+			return text
+		case payload.Message != "":
+			return payload.Message
+		case payload.ErrMsg != "":
+			return payload.ErrMsg
+		}
+	}
+
+	message := strings.TrimSpace(string(body))
+	if len(message) > 500 {
+		message = message[:500] + "..."
+	}
+	return message
+}
@@ -0,0 +1,210 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{
+			name:   "nothing",
+			header: http.Header{},
+			want:   0,
+		},
+		{
+			name:   "seconds",
+			header: http.Header{"Retry-After": {"2"}},
+			want:   2 * time.Second,
+		},
+		{
+			name:   "milliseconds win over seconds",
+			header: http.Header{"Retry-After-Ms": {"250"}, "Retry-After": {"2"}},
+			want:   250 * time.Millisecond,
+		},
+		{
+			name:   "date in the past",
+			header: http.Header{"Retry-After": {"Mon, 02 Jan 2006 15:04:05 GMT"}},
+			want:   0,
+		},
+		{
+			name: "exhausted token window",
+			header: http.Header{
+				"X-Ratelimit-Remaining-Requests": {"10"},
+				"X-Ratelimit-Reset-Requests":     {"1s"},
+				"X-Ratelimit-Remaining-Tokens":   {"0"},
+				"X-Ratelimit-Reset-Tokens":       {"6.5s"},
+			},
+			want: 6500 * time.Millisecond,
+		},
+		{
+			name: "windows that aren't exhausted don't count",
+			header: http.Header{
+				"X-Ratelimit-Remaining-Requests": {"3"},
+				"X-Ratelimit-Reset-Requests":     {"20s"},
+			},
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryAfter(tt.header); got != tt.want {
+				t.Errorf("retryAfter = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "openai",
+			body: `{"error": {"message": "Invalid file format.", "type": "invalid_request_error"}}`,
+			want: "Invalid file format.",
+		},
+		{
+			name: "error string",
+			body: `{"error": "transcript not found"}`,
+			want: "transcript not found",
+		},
+		{
+			name: "message",
+			body: `{"message": "Unauthorized"}`,
+			want: "Unauthorized",
+		},
+		{
+			name: "deepgram",
+			body: `{"err_code": "Bad Request", "err_msg": "Bad Request: failed to process audio"}`,
+			want: "Bad Request: failed to process audio",
+		},
+		{
+			name: "not json",
+			body: "  502 Bad Gateway\n",
+			want: "502 Bad Gateway",
+		},
+		{
+			name: "long bodies are cut",
+			body: strings.Repeat("x", 600),
+			want: strings.Repeat("x", 500) + "...",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorMessage([]byte(tt.body)); got != tt.want {
+				t.Errorf("errorMessage = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int // answered in order, the last one repeats
+		body      string
+		wantCalls int32
+		wantErr   error
+	}{
+		{
+			name:      "retries server errors",
+			statuses:  []int{http.StatusBadGateway, http.StatusOK},
+			wantCalls: 2,
+		},
+		{
+			name:      "retries rate limits",
+			statuses:  []int{http.StatusTooManyRequests, http.StatusOK},
+			wantCalls: 2,
+		},
+		{
+			name:      "gives up after the retries",
+			statuses:  []int{http.StatusServiceUnavailable},
+			wantCalls: 3,
+			wantErr:   ErrServer,
+		},
+		{
+			name:      "out of credit is not retried",
+			statuses:  []int{http.StatusTooManyRequests},
+			body:      `{"error": {"code": "insufficient_quota"}}`,
+			wantCalls: 1,
+			wantErr:   ErrRateLimited,
+		},
+		{
+			name:      "bad input is not retried",
+			statuses:  []int{http.StatusBadRequest},
+			wantCalls: 1,
+			wantErr:   ErrInvalidInput,
+		},
+		{
+			name:      "bad key is not retried",
+			statuses:  []int{http.StatusUnauthorized},
+			wantCalls: 1,
+			wantErr:   ErrUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(atomic.AddInt32(&calls, 1)) - 1
+				status := tt.statuses[min(n, len(tt.statuses)-1)]
+				w.WriteHeader(status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := New(time.Second)
+			client.MaxRetries = 2
+			client.BaseDelay = time.Millisecond
+			client.MaxDelay = time.Millisecond
+
+			req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("payload"))
+			_, err := client.Do(req)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(&calls); got != tt.wantCalls {
+				t.Errorf("server got %d calls, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDoGivesUpOnLongRetryAfter(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := New(time.Second).Do(req)
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter != time.Hour {
+		t.Fatalf("err = %v, want an *Error asking for an hour", err)
+	}
+	if calls != 1 {
+		t.Errorf("server got %d calls, want 1", calls)
+	}
+}
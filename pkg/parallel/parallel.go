@@ -0,0 +1,53 @@
+// Package parallel runs indexed jobs with a cap on how many run at once
+package parallel
+
+import (
+	"context"
+	"sync"
+)
+
+// Run calls fn for 0..n-1 with at most limit at once, a limit below 1 runs them one at a time
+// the first error cancels the ctx passed to the jobs still running and is the one returned,
+// the ones after it are just the cancellation
+func Run(ctx context.Context, n int, limit int, fn func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var firstErr error
+	var errOnce sync.Once
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	// the buffered channel works as a semaphore
+	sem := make(chan struct{}, max(limit, 1))
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				fail(ctx.Err())
+				return
+			}
+			// select picks at random when both are ready, so a freed slot can still race the cancel
+			if ctx.Err() != nil {
+				fail(ctx.Err())
+				return
+			}
+
+			if err := fn(ctx, i); err != nil {
+				fail(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,95 @@
+package parallel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	boom := errors.New("boom")
+
+	tests := []struct {
+		name    string
+		n       int
+		limit   int
+		failAt  int // index that fails, -1 for none
+		wantErr error
+	}{
+		{name: "all succeed", n: 10, limit: 3, failAt: -1},
+		{name: "no limit runs one at a time", n: 5, limit: 0, failAt: -1},
+		{name: "nothing to do", n: 0, limit: 2, failAt: -1},
+		{name: "first error is returned", n: 10, limit: 2, failAt: 4, wantErr: boom},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var running, peak int32
+			var mu sync.Mutex
+			done := map[int]bool{}
+
+			err := Run(context.Background(), tt.n, tt.limit, func(ctx context.Context, i int) error {
+				now := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+				for {
+					old := atomic.LoadInt32(&peak)
+					if now <= old || atomic.CompareAndSwapInt32(&peak, old, now) {
+						break
+					}
+				}
+
+				if i == tt.failAt {
+					return boom
+				}
+				mu.Lock()
+				done[i] = true
+				mu.Unlock()
+				return nil
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if limit := int32(max(tt.limit, 1)); peak > limit {
+				t.Errorf("%d jobs ran at once, limit is %d", peak, limit)
+			}
+			if tt.wantErr == nil && len(done) != tt.n {
+				t.Errorf("%d jobs finished, want %d", len(done), tt.n)
+			}
+		})
+	}
+}
+
+func TestRunCancelsAfterFailure(t *testing.T) {
+	boom := errors.New("boom")
+	var started int32
+
+	// one at a time, so whichever job runs first fails and the rest must never start
+	err := Run(context.Background(), 5, 1, func(ctx context.Context, i int) error {
+		if atomic.AddInt32(&started, 1) == 1 {
+			return boom
+		}
+		return nil
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want boom", err)
+	}
+	if started != 1 {
+		t.Errorf("%d jobs started, want only the failing one", started)
+	}
+}
+
+func TestRunCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := Run(ctx, 3, 2, func(ctx context.Context, i int) error {
+		t.Errorf("job %d started on a cancelled context", i)
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,155 @@
+package qa
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/chat"
+)
+
+// Passage is a run of consecutive transcript segments, the unit retrieval works on
+type Passage struct {
+	Index int // position in the transcript's passages
+	Start float64
+	End   float64
+	Text  string
+	Score float64
+}
+
+// Retriever finds the passages of a transcript that are most relevant to a query
+type Retriever interface {
+	// Retrieve returns at most k passages, best first
+	Retrieve(ctx context.Context, transcript *models.Transcript, query string, k int) ([]Passage, error)
+}
+
+// passageTokens is roughly how long a passage is, a few sentences or about half a minute of speech
+// short enough that a citation points close to the moment it is about
+const passageTokens = 60
+
+// Passages splits a transcript into passages of about passageTokens, never splitting a segment
+// a transcript that is only text is split on sentences and its passages have no timings
+func Passages(transcript *models.Transcript) []Passage {
+	segments := transcript.TextSegments()
+
+	var passages []Passage
+	var texts []string
+	tokens := 0
+	flush := func(end float64) {
+		if len(texts) == 0 {
+			return
+		}
+		passages[len(passages)-1].Text = strings.Join(texts, " ")
+		passages[len(passages)-1].End = end
+		texts, tokens = nil, 0
+	}
+
+	var last models.Segment
+	for _, segment := range segments {
+		text := strings.TrimSpace(segment.Text)
+		if text == "" {
+			continue
+		}
+		if len(texts) > 0 && tokens+chat.EstimateTokens(text) > passageTokens {
+			flush(last.End)
+		}
+		if len(texts) == 0 {
+			passages = append(passages, Passage{Index: len(passages), Start: segment.Start})
+		}
+		texts = append(texts, text)
+		tokens += chat.EstimateTokens(text)
+		last = segment
+	}
+	flush(last.End)
+	return passages
+}
+
+// stopwords are too common to say anything about relevance
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "but": true, "by": true,
+	"did": true, "do": true, "does": true, "for": true, "from": true, "has": true, "have": true, "how": true,
+	"i": true, "in": true, "is": true, "it": true, "of": true, "on": true, "or": true, "so": true, "that": true,
+	"the": true, "their": true, "they": true, "this": true, "to": true, "was": true, "we": true, "were": true,
+	"what": true, "when": true, "where": true, "which": true, "who": true, "why": true, "with": true, "you": true,
+}
+
+// terms lowercases text and splits it into words, dropping stopwords
+func terms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	kept := words[:0]
+	for _, word := range words {
+		if !stopwords[word] {
+			kept = append(kept, word)
+		}
+	}
+	return kept
+}
+
+// LexicalRetriever ranks passages by BM25 over their words
+// it needs no API calls or index, but only finds passages that share words with the question
+type LexicalRetriever struct {
+	K1 float64 // term frequency saturation
+	B  float64 // length normalization
+}
+
+// NewLexicalRetriever creates a BM25 retriever with the usual parameters
+func NewLexicalRetriever() *LexicalRetriever {
+	return &LexicalRetriever{K1: 1.2, B: 0.75}
+}
+
+// Retrieve scores every passage against the query, passages with no words in common are left out
+func (r *LexicalRetriever) Retrieve(ctx context.Context, transcript *models.Transcript, query string, k int) ([]Passage, error) {
+	passages := Passages(transcript)
+	queryTerms := terms(query)
+	if len(passages) == 0 || len(queryTerms) == 0 {
+		return nil, nil
+	}
+
+	// term counts per passage, and how many passages each term appears in
+	counts := make([]map[string]int, len(passages))
+	lengths := make([]int, len(passages))
+	documentFrequency := map[string]int{}
+	total := 0
+	for i, passage := range passages {
+		counts[i] = map[string]int{}
+		for _, term := range terms(passage.Text) {
+			if counts[i][term] == 0 {
+				documentFrequency[term]++
+			}
+			counts[i][term]++
+			lengths[i]++
+		}
+		total += lengths[i]
+	}
+	averageLength := max(float64(total)/float64(len(passages)), 1)
+
+	n := float64(len(passages))
+	var scored []Passage
+	for i, passage := range passages {
+		score := 0.0
+		for _, term := range queryTerms {
+			tf := float64(counts[i][term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(documentFrequency[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			score += idf * tf * (r.K1 + 1) / (tf + r.K1*(1-r.B+r.B*float64(lengths[i])/averageLength))
+		}
+		if score > 0 {
+			passage.Score = score
+			scored = append(scored, passage)
+		}
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+	if len(scored) > k {
+		scored = scored[:k]
+	}
+	return scored, nil
+}
@@ -0,0 +1,138 @@
+package qa
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/internal/models/modelstest"
+)
+
+// padding makes a segment long enough to be a passage on its own
+var padding = strings.Repeat(" filler", 30)
+
+func TestPassages(t *testing.T) {
+	tests := []struct {
+		name       string
+		transcript *models.Transcript
+		want       [][2]float64 // start and end of each passage
+	}{
+		{
+			name: "short segments are joined",
+			transcript: &models.Transcript{Segments: []models.Segment{
+				{Start: 0, End: 2, Text: "Hello."},
+				{Start: 2, End: 5, Text: "Welcome back."},
+			}},
+			want: [][2]float64{{0, 5}},
+		},
+		{
+			name:       "long segments stay apart",
+			transcript: &models.Transcript{Segments: modelstest.Segments(30, "one"+padding, "two"+padding, "three"+padding)},
+			want:       [][2]float64{{0, 30}, {30, 60}, {60, 90}},
+		},
+		{
+			name:       "untimed text is split on sentences",
+			transcript: &models.Transcript{Text: "First." + padding + ". Second." + padding + "."},
+			want:       [][2]float64{{0, 0}, {0, 0}},
+		},
+		{
+			name:       "empty",
+			transcript: &models.Transcript{},
+			want:       nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][2]float64
+			for i, passage := range Passages(tt.transcript) {
+				if passage.Index != i {
+					t.Errorf("passage %d has index %d", i, passage.Index)
+				}
+				got = append(got, [2]float64{passage.Start, passage.End})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("passages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTerms(t *testing.T) {
+	got := terms("What is the Kubernetes rollout, and how did it go? v1.2")
+	if want := []string{"kubernetes", "rollout", "go", "v1", "2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("terms = %q, want %q", got, want)
+	}
+}
+
+func TestLexicalRetriever(t *testing.T) {
+	transcript := &models.Transcript{Segments: modelstest.Segments(30,
+		"We start with the agenda."+padding,
+		"Kubernetes came up once here."+padding,
+		"Kubernetes, Kubernetes, the Kubernetes rollout went fine."+padding,
+		"Lunch plans."+padding,
+	)}
+
+	tests := []struct {
+		name  string
+		query string
+		k     int
+		want  []int // passage indexes, best first
+	}{
+		{
+			name:  "more matches rank higher and unrelated passages are left out",
+			query: "How did the Kubernetes rollout go?",
+			k:     5,
+			want:  []int{2, 1},
+		},
+		{
+			name:  "k limits the results",
+			query: "kubernetes",
+			k:     1,
+			want:  []int{2},
+		},
+		{
+			name:  "matching is case insensitive",
+			query: "LUNCH",
+			k:     5,
+			want:  []int{3},
+		},
+		{
+			name:  "only stopwords",
+			query: "what is this?",
+			k:     5,
+			want:  nil,
+		},
+		{
+			name:  "nothing in common",
+			query: "database migration",
+			k:     5,
+			want:  nil,
+		},
+	}
+
+	retriever := NewLexicalRetriever()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			passages, err := retriever.Retrieve(context.Background(), transcript, tt.query, tt.k)
+			if err != nil {
+				t.Fatalf("Retrieve: %v", err)
+			}
+			var got []int
+			for i, passage := range passages {
+				got = append(got, passage.Index)
+				if passage.Score <= 0 {
+					t.Errorf("passage %d has score %v", passage.Index, passage.Score)
+				}
+				if i > 0 && passage.Score > passages[i-1].Score {
+					t.Errorf("passages are not sorted by score")
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("passages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
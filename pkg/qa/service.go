@@ -0,0 +1,148 @@
+// Package qa answers questions about a video from its transcript
+// the relevant passages are retrieved first and the model is asked to cite them,
+// so every answer can link back to where in the video it came from
+package qa
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/chat"
+)
+
+// Answer is the reply to a question with the passages it cites
+type Answer struct {
+	Text      string
+	Citations []models.Citation // only the passages the answer actually cites, by number
+	Passages  []Passage         // everything that was retrieved and shown to the model
+}
+
+// Service defines the interface for answering questions about a transcript
+type Service interface {
+	// Ask answers question using the transcript, history is the session so far, oldest first
+	Ask(ctx context.Context, transcript *models.Transcript, question string, history []models.Turn) (*Answer, error)
+}
+
+// ChatService answers questions through the chat completions API
+type ChatService struct {
+	Client       *chat.Client
+	Model        string
+	Retriever    Retriever
+	Passages     int // passages retrieved per question
+	HistoryTurns int // most recent session turns sent along with the question
+}
+
+// NewChatService creates a question answering service using the given chat client and retriever
+func NewChatService(client *chat.Client, retriever Retriever) *ChatService {
+	return &ChatService{
+		Client:       client,
+		Model:        "gpt-3.5-turbo",
+		Retriever:    retriever,
+		Passages:     8,
+		HistoryTurns: 10,
+	}
+}
+
+// citationPattern finds [n] and [n, m] markers in an answer
+var citationPattern = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// Ask retrieves passages for the question and asks the model to answer from them
+// follow-ups like "and what about the second one?" say little on their own, so the
+// previous question is added to the retrieval query
+func (s *ChatService) Ask(ctx context.Context, transcript *models.Transcript, question string, history []models.Turn) (*Answer, error) {
+	query := question
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == "user" {
+			query = history[i].Content + " " + question
+			break
+		}
+	}
+
+	passages, err := s.Retriever.Retrieve(ctx, transcript, query, max(s.Passages, 1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve passages: %w", err)
+	}
+	// show them in the order they happen in the video, which reads better than by score
+	sort.SliceStable(passages, func(i, j int) bool { return passages[i].Index < passages[j].Index })
+
+	var excerpts strings.Builder
+	for i, passage := range passages {
+		fmt.Fprintf(&excerpts, "[%d]", i+1)
+		if passage.End > 0 {
+			fmt.Fprintf(&excerpts, " (%s - %s)", models.FormatTime(passage.Start), models.FormatTime(passage.End))
+		}
+		fmt.Fprintf(&excerpts, " %s\n\n", passage.Text)
+	}
+	if len(passages) == 0 {
+		excerpts.WriteString("(no part of the transcript matched this question)\n")
+	}
+
+	messages := []chat.Message{
+		{
+			Role: "system",
+			Content: "You answer questions about a video using only the numbered transcript excerpts you are given. " +
+				"Cite the excerpts each statement comes from with their numbers in square brackets, like [1] or [2, 3]. " +
+				"If the excerpts don't contain the answer, say the video doesn't seem to cover it rather than guessing. " +
+				"Answer in the language of the question.",
+		},
+	}
+	// earlier turns without their excerpts, enough to resolve what a follow-up refers to
+	if turns := max(s.HistoryTurns, 0); len(history) > turns {
+		history = history[len(history)-turns:]
+	}
+	for _, turn := range history {
+		messages = append(messages, chat.Message{Role: turn.Role, Content: turn.Content})
+	}
+	messages = append(messages, chat.Message{
+		Role:    "user",
+		Content: fmt.Sprintf("Transcript excerpts:\n\n%sQuestion: %s", excerpts.String(), question),
+	})
+
+	text, err := s.Client.Complete(ctx, chat.Request{
+		Model:       s.Model,
+		Messages:    messages,
+		Temperature: 0.2, // Low temperature keeps answers close to the excerpts
+	})
+	if err != nil {
+		return nil, err
+	}
+	text = strings.TrimSpace(text)
+
+	return &Answer{
+		Text:      text,
+		Citations: citations(text, passages),
+		Passages:  passages,
+	}, nil
+}
+
+// citations returns the passages an answer cites, in the order of their numbers
+// numbers that don't match a passage are ignored
+func citations(text string, passages []Passage) []models.Citation {
+	cited := map[int]bool{}
+	for _, match := range citationPattern.FindAllStringSubmatch(text, -1) {
+		for _, number := range strings.Split(match[1], ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(number))
+			if err == nil && n >= 1 && n <= len(passages) {
+				cited[n] = true
+			}
+		}
+	}
+
+	result := []models.Citation{}
+	for i, passage := range passages {
+		if cited[i+1] {
+			result = append(result, models.Citation{
+				Number: i + 1,
+				Start:  passage.Start,
+				End:    passage.End,
+				Text:   passage.Text,
+			})
+		}
+	}
+	return result
+}
@@ -0,0 +1,110 @@
+package qa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/chat"
+)
+
+func TestCitations(t *testing.T) {
+	passages := []Passage{
+		{Start: 0, End: 30, Text: "one"},
+		{Start: 30, End: 60, Text: "two"},
+		{Start: 60, End: 90, Text: "three"},
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want []int
+	}{
+		{name: "none", text: "The video doesn't seem to cover it.", want: []int{}},
+		{name: "single", text: "It went fine [2].", want: []int{2}},
+		{name: "list", text: "It went fine [3, 1].", want: []int{1, 3}},
+		{name: "repeated", text: "Yes [1]. Also yes [1,2].", want: []int{1, 2}},
+		{name: "out of range", text: "Maybe [0] [4] [2].", want: []int{2}},
+		{name: "not a citation", text: "See [a] and [1-2].", want: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []int{}
+			for _, citation := range citations(tt.text, passages) {
+				got = append(got, citation.Number)
+				if passage := passages[citation.Number-1]; citation.Start != passage.Start || citation.Text != passage.Text {
+					t.Errorf("citation %d = %+v, want passage %+v", citation.Number, citation, passage)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("citations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fixedRetriever returns the same passages for every query and remembers the query
+type fixedRetriever struct {
+	passages []Passage
+	query    string
+}
+
+func (r *fixedRetriever) Retrieve(ctx context.Context, transcript *models.Transcript, query string, k int) ([]Passage, error) {
+	r.query = query
+	return append([]Passage(nil), r.passages...), nil
+}
+
+func TestAsk(t *testing.T) {
+	var request chat.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&request)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []interface{}{map[string]interface{}{"message": map[string]string{"content": " It went fine [2]. "}}},
+		})
+	}))
+	defer server.Close()
+
+	client := chat.NewClient("test-key")
+	client.BaseURL = server.URL
+	retriever := &fixedRetriever{passages: []Passage{
+		{Index: 7, Start: 210, End: 240, Text: "the rollout went fine", Score: 3},
+		{Index: 2, Start: 60, End: 90, Text: "we planned the rollout", Score: 1},
+	}}
+	service := NewChatService(client, retriever)
+
+	history := []models.Turn{
+		{Role: "user", Content: "What was the rollout plan?"},
+		{Role: "assistant", Content: "They planned it in two steps [1]."},
+	}
+	answer, err := service.Ask(context.Background(), &models.Transcript{}, "And how did it go?", history)
+	if err != nil {
+		t.Fatalf("Ask: %v", err)
+	}
+
+	// a follow-up is retrieved together with the question before it
+	if want := "What was the rollout plan? And how did it go?"; retriever.query != want {
+		t.Errorf("query = %q, want %q", retriever.query, want)
+	}
+
+	// excerpts are numbered in video order, so [2] is the later passage
+	prompt := request.Messages[len(request.Messages)-1].Content
+	if !strings.Contains(prompt, "[1] (1:00 - 1:30) we planned the rollout") || !strings.Contains(prompt, "[2] (3:30 - 4:00) the rollout went fine") {
+		t.Errorf("prompt does not list the excerpts in video order:\n%s", prompt)
+	}
+	if len(request.Messages) != 4 {
+		t.Errorf("sent %d messages, want system, two history turns and the question", len(request.Messages))
+	}
+
+	if answer.Text != "It went fine [2]." {
+		t.Errorf("answer = %q", answer.Text)
+	}
+	if len(answer.Citations) != 1 || answer.Citations[0].Start != 210 {
+		t.Errorf("citations = %+v, want the passage at 210s", answer.Citations)
+	}
+}
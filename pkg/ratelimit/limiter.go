@@ -0,0 +1,213 @@
+// Package ratelimit keeps our calls inside the provider's quotas
+// calls wait for capacity instead of being sent and bouncing off a 429
+package ratelimit
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits are the quotas for one API key, zero means unlimited
+type Limits struct {
+	RequestsPerMinute int `json:"requests_per_minute"`
+	TokensPerMinute   int `json:"tokens_per_minute"`
+	MaxInFlight       int `json:"max_in_flight"`
+}
+
+// envPrefixPattern is what can't appear in an environment variable name
+var envPrefixPattern = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// LimitsFromEnv reads <PREFIX>_REQUESTS_PER_MINUTE, <PREFIX>_TOKENS_PER_MINUTE and
+// <PREFIX>_MAX_IN_FLIGHT, e.g. LimitsFromEnv("openai") reads OPENAI_REQUESTS_PER_MINUTE
+func LimitsFromEnv(prefix string) Limits {
+	prefix = envPrefixPattern.ReplaceAllString(strings.ToUpper(prefix), "_") + "_"
+	read := func(name string) int {
+		value, _ := strconv.Atoi(os.Getenv(prefix + name))
+		return max(value, 0)
+	}
+	return Limits{
+		RequestsPerMinute: read("REQUESTS_PER_MINUTE"),
+		TokensPerMinute:   read("TOKENS_PER_MINUTE"),
+		MaxInFlight:       read("MAX_IN_FLIGHT"),
+	}
+}
+
+// Limiter queues calls so they stay within Limits
+// requests and tokens are token buckets that refill continuously over a minute,
+// so a burst can use the whole minute's quota and then calls trickle through
+type Limiter struct {
+	limits   Limits
+	inFlight chan struct{} // nil when MaxInFlight is unlimited
+
+	mu       sync.Mutex
+	requests float64 // available now
+	tokens   float64
+	updated  time.Time
+	waiting  int
+}
+
+// NewLimiter creates a limiter that starts with the full minute's quota available
+func NewLimiter(limits Limits) *Limiter {
+	l := &Limiter{
+		limits:   limits,
+		requests: float64(limits.RequestsPerMinute),
+		tokens:   float64(limits.TokensPerMinute),
+		updated:  time.Now(),
+	}
+	if limits.MaxInFlight > 0 {
+		l.inFlight = make(chan struct{}, limits.MaxInFlight)
+	}
+	return l
+}
+
+// Acquire waits until a call costing tokens fits in the quotas, then returns a release
+// func that must be called when the call is done; it only fails if ctx is cancelled
+// a nil limiter lets everything through
+func (l *Limiter) Acquire(ctx context.Context, tokens int) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	l.mu.Lock()
+	l.waiting++
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		l.waiting--
+		l.mu.Unlock()
+	}()
+
+	// take the in-flight slot first, a call cancelled while waiting for it hasn't used any quota yet
+	release := func() {}
+	if l.inFlight != nil {
+		select {
+		case l.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		var once sync.Once
+		release = func() {
+			once.Do(func() { <-l.inFlight })
+		}
+	}
+
+	for {
+		wait := l.reserve(tokens)
+		if wait == 0 {
+			break
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			release()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	return release, nil
+}
+
+// reserve takes a request and the tokens if they are available, otherwise it
+// returns how long until they should be
+func (l *Limiter) reserve(tokens int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(l.updated).Minutes()
+	l.updated = now
+
+	rpm := float64(l.limits.RequestsPerMinute)
+	tpm := float64(l.limits.TokensPerMinute)
+	if rpm > 0 {
+		l.requests = min(rpm, l.requests+elapsed*rpm)
+	}
+
+	// a call bigger than the whole minute's budget would wait forever, let it through when the bucket is full
+	need := float64(tokens)
+	if tpm > 0 {
+		l.tokens = min(tpm, l.tokens+elapsed*tpm)
+		need = min(need, tpm)
+	}
+
+	var wait time.Duration
+	if rpm > 0 && l.requests < 1 {
+		wait = max(wait, time.Duration((1-l.requests)/rpm*float64(time.Minute)))
+	}
+	if tpm > 0 && l.tokens < need {
+		wait = max(wait, time.Duration((need-l.tokens)/tpm*float64(time.Minute)))
+	}
+	if wait > 0 {
+		// never spin on tiny waits
+		return max(wait, 10*time.Millisecond)
+	}
+
+	if rpm > 0 {
+		l.requests--
+	}
+	if tpm > 0 {
+		l.tokens -= need
+	}
+	return 0
+}
+
+// Stats is a snapshot of a limiter for diagnostics
+type Stats struct {
+	Limits            Limits `json:"limits"`
+	InFlight          int    `json:"in_flight"`
+	Waiting           int    `json:"waiting"`
+	RequestsAvailable int    `json:"requests_available"`
+	TokensAvailable   int    `json:"tokens_available"`
+}
+
+// Stats returns the limiter's current state
+func (l *Limiter) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return Stats{
+		Limits:            l.limits,
+		InFlight:          len(l.inFlight),
+		Waiting:           l.waiting,
+		RequestsAvailable: int(l.requests),
+		TokensAvailable:   int(l.tokens),
+	}
+}
+
+// Registry hands out one limiter per API key, so every service using the same key
+// (Whisper, summaries, translations) shares that key's quota
+type Registry struct {
+	mu       sync.Mutex
+	limiters map[string]*Limiter
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		limiters: map[string]*Limiter{},
+	}
+}
+
+// For returns the limiter for apiKey, creating it with limits the first time the key is seen
+// keys with no limits at all get no limiter
+func (r *Registry) For(apiKey string, limits Limits) *Limiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if limiter, ok := r.limiters[apiKey]; ok {
+		return limiter
+	}
+	if limits == (Limits{}) {
+		return nil
+	}
+	limiter := NewLimiter(limits)
+	r.limiters[apiKey] = limiter
+	return limiter
+}
@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReserve(t *testing.T) {
+	tests := []struct {
+		name     string
+		limits   Limits
+		calls    []int // tokens per call, all but the last must go through
+		wantWait bool  // whether the last call has to wait
+	}{
+		{
+			name:   "unlimited",
+			limits: Limits{},
+			calls:  []int{1000, 1000, 1000},
+		},
+		{
+			name:     "requests run out",
+			limits:   Limits{RequestsPerMinute: 2},
+			calls:    []int{0, 0, 0},
+			wantWait: true,
+		},
+		{
+			name:   "tokens fit",
+			limits: Limits{TokensPerMinute: 100},
+			calls:  []int{40, 60},
+		},
+		{
+			name:     "tokens run out",
+			limits:   Limits{TokensPerMinute: 100},
+			calls:    []int{60, 60},
+			wantWait: true,
+		},
+		{
+			name:   "call bigger than the minute goes through on a full bucket",
+			limits: Limits{TokensPerMinute: 100},
+			calls:  []int{500},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLimiter(tt.limits)
+			last := len(tt.calls) - 1
+			for i, tokens := range tt.calls[:last] {
+				if wait := l.reserve(tokens); wait != 0 {
+					t.Fatalf("call %d waited %v, want it to go through", i, wait)
+				}
+			}
+			wait := l.reserve(tt.calls[last])
+			if (wait > 0) != tt.wantWait {
+				t.Errorf("last call waited %v, want wait %v", wait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestReserveWaitsForRefill(t *testing.T) {
+	l := NewLimiter(Limits{RequestsPerMinute: 60})
+	l.requests = 0
+	l.updated = time.Now()
+
+	// one request refills every second
+	wait := l.reserve(0)
+	if wait < 900*time.Millisecond || wait > time.Second {
+		t.Errorf("wait = %v, want about a second", wait)
+	}
+}
+
+func TestAcquireCancelledKeepsQuota(t *testing.T) {
+	l := NewLimiter(Limits{RequestsPerMinute: 2, MaxInFlight: 1})
+
+	release, err := l.Acquire(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	// the only slot is taken, so this one is cancelled while waiting for it
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Acquire err = %v, want deadline exceeded", err)
+	}
+	release()
+
+	if stats := l.Stats(); stats.InFlight != 0 {
+		t.Errorf("in flight = %d after release, want 0", stats.InFlight)
+	}
+
+	// the first call used one of the minute's two requests, the cancelled one must not have taken the other
+	if wait := l.reserve(0); wait != 0 {
+		t.Errorf("reserve waited %v after a cancelled Acquire, want the request still available", wait)
+	}
+}
+
+func TestAcquireCancelledWhileWaitingForQuotaReleasesSlot(t *testing.T) {
+	l := NewLimiter(Limits{RequestsPerMinute: 1, MaxInFlight: 1})
+	l.requests = 0
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Acquire err = %v, want deadline exceeded", err)
+	}
+	if stats := l.Stats(); stats.InFlight != 0 {
+		t.Errorf("in flight = %d after a cancelled Acquire, want 0", stats.InFlight)
+	}
+}
+
+func TestNilLimiter(t *testing.T) {
+	var l *Limiter
+	release, err := l.Acquire(context.Background(), 1000)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	release()
+}
@@ -0,0 +1,276 @@
+// Package search finds the videos and moments that talk about a topic
+// transcripts are split into passages, embedded, and kept in a local vector index
+package search
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Entry is one embedded passage of a video's transcript
+type Entry struct {
+	VideoID string
+	Start   float64
+	End     float64
+	Text    string
+	Vector  []float32 // normalized to length 1, so a dot product is the cosine similarity
+}
+
+// Match is an entry found by a search with its similarity to the query
+type Match struct {
+	Entry
+	Score float64
+}
+
+// indexFile is what is written to disk
+// Stale lists the videos indexed with an earlier model that still have to be reindexed
+type indexFile struct {
+	Model  string
+	Videos map[string][]Entry
+	Stale  []string
+}
+
+// Index is an in-memory vector index saved to a single file after every change
+// a brute-force scan is plenty for a library of a few thousand hours of video
+type Index struct {
+	Path string
+
+	mu      sync.RWMutex
+	model   string
+	videos  map[string][]Entry
+	stale   map[string]bool
+	version int // bumped on every change, so an older snapshot never overwrites a newer one
+
+	saveMu sync.Mutex
+	saved  int
+}
+
+// OpenIndex loads the index at path for vectors from model, starting an empty one if the file doesn't exist yet
+// vectors made with another model can't be compared, so when the model has changed the old
+// vectors are dropped and their videos are kept in Stale until they are reindexed
+func OpenIndex(path string, model string) (*Index, error) {
+	index := &Index{
+		Path:   path,
+		model:  model,
+		videos: map[string][]Entry{},
+		stale:  map[string]bool{},
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open search index: %w", err)
+	}
+	defer file.Close()
+
+	var data indexFile
+	if err := gob.NewDecoder(file).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode search index: %w", err)
+	}
+	for _, videoID := range data.Stale {
+		index.stale[videoID] = true
+	}
+	if data.Model == model {
+		if data.Videos != nil {
+			index.videos = data.Videos
+		}
+		return index, nil
+	}
+
+	for videoID := range data.Videos {
+		index.stale[videoID] = true
+	}
+	if len(index.stale) > 0 {
+		fmt.Printf("Warning: search index model changed from %s to %s, %d videos need reindexing (POST /api/search/reindex)\n", data.Model, model, len(index.stale))
+		// record it right away, so a restart before the reindex still knows which videos are stale
+		if err := index.save(index.snapshot()); err != nil {
+			return nil, err
+		}
+	}
+	return index, nil
+}
+
+// Model is the embedding model the index's vectors come from
+func (i *Index) Model() string {
+	return i.model
+}
+
+// Put replaces a video's entries
+func (i *Index) Put(videoID string, entries []Entry) error {
+	for j := range entries {
+		entries[j].Vector = normalize(entries[j].Vector)
+	}
+
+	i.mu.Lock()
+	i.videos[videoID] = entries
+	delete(i.stale, videoID)
+	snapshot := i.snapshot()
+	i.mu.Unlock()
+
+	return i.save(snapshot)
+}
+
+// Remove drops a video from the index
+func (i *Index) Remove(videoID string) error {
+	i.mu.Lock()
+	_, indexed := i.videos[videoID]
+	if !indexed && !i.stale[videoID] {
+		i.mu.Unlock()
+		return nil
+	}
+	delete(i.videos, videoID)
+	delete(i.stale, videoID)
+	snapshot := i.snapshot()
+	i.mu.Unlock()
+
+	return i.save(snapshot)
+}
+
+// Stale returns the videos that were indexed with an earlier model and need reindexing
+func (i *Index) Stale() []string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	videoIDs := make([]string, 0, len(i.stale))
+	for videoID := range i.stale {
+		videoIDs = append(videoIDs, videoID)
+	}
+	sort.Strings(videoIDs)
+	return videoIDs
+}
+
+// IsStale reports whether the video still waits on a reindex
+func (i *Index) IsStale(videoID string) bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return i.stale[videoID]
+}
+
+// Search returns the k entries most similar to the query vector, best first
+func (i *Index) Search(query []float32, k int) []Match {
+	query = normalize(query)
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	var matches []Match
+	for _, entries := range i.videos {
+		for _, entry := range entries {
+			if len(entry.Vector) != len(query) {
+				continue
+			}
+			matches = append(matches, Match{Entry: entry, Score: dot(entry.Vector, query)})
+		}
+	}
+
+	sort.Slice(matches, func(a, b int) bool { return matches[a].Score > matches[b].Score })
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches
+}
+
+// Stats reports how many videos and passages are indexed, and how many videos wait on a reindex
+func (i *Index) Stats() (videos int, passages int, stale int) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	for _, entries := range i.videos {
+		passages += len(entries)
+	}
+	return len(i.videos), passages, len(i.stale)
+}
+
+// indexSnapshot is the index's contents at one version, to save without holding the lock
+type indexSnapshot struct {
+	version int
+	file    indexFile
+}
+
+// snapshot copies the maps (entry slices are replaced, never changed, so they can be shared)
+// the caller holds the write lock
+func (i *Index) snapshot() indexSnapshot {
+	i.version++
+	videos := make(map[string][]Entry, len(i.videos))
+	for videoID, entries := range i.videos {
+		videos[videoID] = entries
+	}
+	stale := make([]string, 0, len(i.stale))
+	for videoID := range i.stale {
+		stale = append(stale, videoID)
+	}
+	return indexSnapshot{
+		version: i.version,
+		file:    indexFile{Model: i.model, Videos: videos, Stale: stale},
+	}
+}
+
+// save writes a snapshot to a temp file and renames it over the old one,
+// so a crash mid-write never leaves a truncated index; searches carry on meanwhile
+func (i *Index) save(snapshot indexSnapshot) error {
+	i.saveMu.Lock()
+	defer i.saveMu.Unlock()
+
+	// a newer snapshot already made it to disk
+	if snapshot.version <= i.saved {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(i.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create search index directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(i.Path), filepath.Base(i.Path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save search index: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if err := gob.NewEncoder(file).Encode(snapshot.file); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to save search index: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to save search index: %w", err)
+	}
+	if err := os.Rename(file.Name(), i.Path); err != nil {
+		return fmt.Errorf("failed to save search index: %w", err)
+	}
+	i.saved = snapshot.version
+	return nil
+}
+
+// normalize scales a vector to length 1, most APIs already do but not every self-hosted model
+func normalize(vector []float32) []float32 {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return vector
+	}
+	norm := float32(math.Sqrt(sum))
+	normalized := make([]float32, len(vector))
+	for j, v := range vector {
+		normalized[j] = v / norm
+	}
+	return normalized
+}
+
+// dot is the dot product of two vectors of the same length
+func dot(a []float32, b []float32) float64 {
+	var sum float64
+	for j := range a {
+		sum += float64(a[j]) * float64(b[j])
+	}
+	return sum
+}
@@ -0,0 +1,86 @@
+package search
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIndexSearch(t *testing.T) {
+	index, err := OpenIndex(filepath.Join(t.TempDir(), "index.gob"), "model-a")
+	if err != nil {
+		t.Fatalf("OpenIndex: %v", err)
+	}
+
+	if err := index.Put("video-1", []Entry{
+		{VideoID: "video-1", Text: "cats", Vector: []float32{1, 0}},
+		{VideoID: "video-1", Text: "dogs", Vector: []float32{0, 3}},
+	}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := index.Put("video-2", []Entry{
+		{VideoID: "video-2", Text: "mostly cats", Vector: []float32{2, 1}},
+	}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	matches := index.Search([]float32{1, 0}, 2)
+	var texts []string
+	for _, match := range matches {
+		texts = append(texts, match.Text)
+	}
+	if want := []string{"cats", "mostly cats"}; !reflect.DeepEqual(texts, want) {
+		t.Errorf("Search = %v, want %v", texts, want)
+	}
+}
+
+func TestIndexReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.gob")
+	index, err := OpenIndex(path, "model-a")
+	if err != nil {
+		t.Fatalf("OpenIndex: %v", err)
+	}
+	for _, videoID := range []string{"video-1", "video-2"} {
+		if err := index.Put(videoID, []Entry{{VideoID: videoID, Vector: []float32{1, 0}}}); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+
+	t.Run("same model keeps the vectors", func(t *testing.T) {
+		reopened, err := OpenIndex(path, "model-a")
+		if err != nil {
+			t.Fatalf("OpenIndex: %v", err)
+		}
+		if videos, _, stale := reopened.Stats(); videos != 2 || stale != 0 {
+			t.Errorf("Stats = %d videos, %d stale, want 2 and 0", videos, stale)
+		}
+	})
+
+	t.Run("new model marks every video stale", func(t *testing.T) {
+		reopened, err := OpenIndex(path, "model-b")
+		if err != nil {
+			t.Fatalf("OpenIndex: %v", err)
+		}
+		if want := []string{"video-1", "video-2"}; !reflect.DeepEqual(reopened.Stale(), want) {
+			t.Errorf("Stale = %v, want %v", reopened.Stale(), want)
+		}
+		if matches := reopened.Search([]float32{1, 0}, 10); len(matches) != 0 {
+			t.Errorf("Search found %d old-model matches, want none", len(matches))
+		}
+
+		// reindexing one video only clears that one, and the rest is still stale after a restart
+		if err := reopened.Put("video-1", []Entry{{VideoID: "video-1", Vector: []float32{0, 1}}}); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+		again, err := OpenIndex(path, "model-b")
+		if err != nil {
+			t.Fatalf("OpenIndex: %v", err)
+		}
+		if want := []string{"video-2"}; !reflect.DeepEqual(again.Stale(), want) {
+			t.Errorf("Stale after reopening = %v, want %v", again.Stale(), want)
+		}
+		if videos, _, _ := again.Stats(); videos != 1 {
+			t.Errorf("indexed videos = %d, want 1", videos)
+		}
+	})
+}
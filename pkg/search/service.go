@@ -0,0 +1,120 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/embeddings"
+	"github.com/ahmadbasyouni10/videogpt/pkg/qa"
+)
+
+// ErrEmptyTranscript is returned when a transcript has no text to index
+var ErrEmptyTranscript = errors.New("transcript has no text to index")
+
+// Service indexes transcripts and searches across them
+type Service struct {
+	Embeddings       embeddings.Service
+	Index            *Index
+	PassagesPerVideo int // matching passages returned for each video
+}
+
+// NewService creates a search service over the given index
+func NewService(embedder embeddings.Service, index *Index) *Service {
+	return &Service{
+		Embeddings:       embedder,
+		Index:            index,
+		PassagesPerVideo: 3,
+	}
+}
+
+// PassageResult is a matching moment in a video
+type PassageResult struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+	Score float64 `json:"score"`
+}
+
+// VideoResult is a video that matched a search, scored by its best passage
+type VideoResult struct {
+	VideoID  string          `json:"video_id"`
+	Score    float64         `json:"score"`
+	Passages []PassageResult `json:"passages"`
+}
+
+// IndexTranscript embeds a transcript's passages and replaces the video's entries in the index
+// passages are the same ones question answering cites, so search results land on the same moments
+func (s *Service) IndexTranscript(ctx context.Context, transcript *models.Transcript) error {
+	passages := qa.Passages(transcript)
+	if len(passages) == 0 {
+		return ErrEmptyTranscript
+	}
+
+	texts := make([]string, len(passages))
+	for i, passage := range passages {
+		texts[i] = passage.Text
+	}
+	vectors, err := s.Embeddings.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to embed transcript: %w", err)
+	}
+
+	entries := make([]Entry, len(passages))
+	for i, passage := range passages {
+		entries[i] = Entry{
+			VideoID: transcript.VideoID,
+			Start:   passage.Start,
+			End:     passage.End,
+			Text:    passage.Text,
+			Vector:  vectors[i],
+		}
+	}
+	return s.Index.Put(transcript.VideoID, entries)
+}
+
+// Search returns up to limit videos ranked by how well their best passage matches the query
+func (s *Service) Search(ctx context.Context, query string, limit int) ([]VideoResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return []VideoResult{}, nil
+	}
+
+	vectors, err := s.Embeddings.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, errors.New("no embedding was returned for the query")
+	}
+
+	// enough passages that the top videos each have a few, matches come back best first
+	perVideo := max(s.PassagesPerVideo, 1)
+	matches := s.Index.Search(vectors[0], limit*perVideo*4)
+
+	results := []VideoResult{}
+	byVideo := map[string]int{}
+	for _, match := range matches {
+		i, ok := byVideo[match.VideoID]
+		if !ok {
+			if len(results) == limit {
+				continue
+			}
+			i = len(results)
+			byVideo[match.VideoID] = i
+			results = append(results, VideoResult{VideoID: match.VideoID, Score: match.Score})
+		}
+		// a video's weaker passages are only worth showing when they are close to its best one
+		if len(results[i].Passages) < perVideo && match.Score >= results[i].Score*0.8 {
+			results[i].Passages = append(results[i].Passages, PassageResult{
+				Start: match.Start,
+				End:   match.End,
+				Text:  match.Text,
+				Score: match.Score,
+			})
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,94 @@
+package summarization
+
+import (
+	"strings"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/chat"
+)
+
+// chunk is a run of consecutive segments that fits in one prompt
+type chunk struct {
+	segments []models.Segment
+}
+
+func (c chunk) text() string {
+	texts := make([]string, len(c.segments))
+	for i, segment := range c.segments {
+		texts[i] = segment.Text
+	}
+	return strings.Join(texts, " ")
+}
+
+func (c chunk) start() float64 {
+	return c.segments[0].Start
+}
+
+func (c chunk) end() float64 {
+	return c.segments[len(c.segments)-1].End
+}
+
+// label says where the chunk is in the recording, empty for untimed text
+func (c chunk) label() string {
+	if c.end() == 0 {
+		return ""
+	}
+	return models.FormatTime(c.start()) + " - " + models.FormatTime(c.end())
+}
+
+// chunkSegments packs segments into chunks of at most maxTokens, never splitting a segment
+// each chunk after the first starts with about overlapTokens of the previous chunk's
+// last segments, so a point made across the boundary isn't lost to either side
+func chunkSegments(segments []models.Segment, maxTokens int, overlapTokens int) []chunk {
+	var chunks []chunk
+	var current []models.Segment
+	tokens := 0
+	fresh := 0 // segments in current that aren't overlap
+
+	for _, segment := range segments {
+		cost := chat.EstimateTokens(segment.Text) + 1
+		if fresh > 0 && tokens+cost > maxTokens {
+			chunks = append(chunks, chunk{segments: current})
+
+			// carry the tail of this chunk into the next one
+			var overlap []models.Segment
+			overlapCost := 0
+			for i := len(current) - 1; i >= 0; i-- {
+				segmentCost := chat.EstimateTokens(current[i].Text) + 1
+				if overlapCost+segmentCost > overlapTokens || overlapCost+segmentCost+cost > maxTokens {
+					break
+				}
+				overlap = append([]models.Segment{current[i]}, overlap...)
+				overlapCost += segmentCost
+			}
+			current, tokens, fresh = overlap, overlapCost, 0
+		}
+		current = append(current, segment)
+		tokens += cost
+		fresh++
+	}
+	if fresh > 0 {
+		chunks = append(chunks, chunk{segments: current})
+	}
+	return chunks
+}
+
+// groupTexts packs texts into groups of at most maxTokens, a text that is too big on its own gets its own group
+func groupTexts(texts []string, maxTokens int) [][]string {
+	var groups [][]string
+	var current []string
+	tokens := 0
+	for _, text := range texts {
+		cost := chat.EstimateTokens(text) + 2
+		if len(current) > 0 && tokens+cost > maxTokens {
+			groups = append(groups, current)
+			current, tokens = nil, 0
+		}
+		current = append(current, text)
+		tokens += cost
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
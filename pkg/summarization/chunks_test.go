@@ -0,0 +1,139 @@
+package summarization
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/internal/models/modelstest"
+)
+
+func TestChunkSegments(t *testing.T) {
+	// 8 characters is 2 tokens, plus 1 for the separator
+	small := "aaaaaaaa"
+	big := strings.Repeat("b", 40)
+
+	tests := []struct {
+		name     string
+		segments []models.Segment
+		max      int
+		overlap  int
+		want     [][]int // segment IDs per chunk
+	}{
+		{
+			name: "empty",
+			max:  9,
+			want: nil,
+		},
+		{
+			name:     "fits in one",
+			segments: modelstest.Segments(10, small, small),
+			max:      9,
+			want:     [][]int{{0, 1}},
+		},
+		{
+			name:     "no overlap",
+			segments: modelstest.Segments(10, small, small, small, small, small, small),
+			max:      9,
+			want:     [][]int{{0, 1, 2}, {3, 4, 5}},
+		},
+		{
+			name:     "overlap carries the last segment over",
+			segments: modelstest.Segments(10, small, small, small, small, small, small),
+			max:      9,
+			overlap:  3,
+			want:     [][]int{{0, 1, 2}, {2, 3, 4}, {4, 5}},
+		},
+		{
+			name:     "oversized segment is kept whole and gets no overlap",
+			segments: modelstest.Segments(10, small, big, small),
+			max:      9,
+			overlap:  3,
+			want:     [][]int{{0}, {1}, {2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]int
+			for _, c := range chunkSegments(tt.segments, tt.max, tt.overlap) {
+				var ids []int
+				for _, segment := range c.segments {
+					ids = append(ids, segment.ID)
+				}
+				got = append(got, ids)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("chunks = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunkLabel(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments []models.Segment
+		want     string
+	}{
+		{
+			name:     "timed",
+			segments: modelstest.Segments(10, "a", "b", "c"),
+			want:     "0:00 - 0:30",
+		},
+		{
+			name:     "untimed",
+			segments: []models.Segment{{Text: "a"}, {Text: "b"}},
+			want:     "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := chunk{segments: tt.segments}
+			if got := c.label(); got != tt.want {
+				t.Errorf("label = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupTexts(t *testing.T) {
+	// 8 characters is 2 tokens, plus 2 for the separator
+	small := "aaaaaaaa"
+	big := strings.Repeat("b", 40)
+
+	tests := []struct {
+		name  string
+		texts []string
+		max   int
+		want  [][]string
+	}{
+		{
+			name: "empty",
+			max:  8,
+			want: nil,
+		},
+		{
+			name:  "pairs",
+			texts: []string{small, small, small},
+			max:   8,
+			want:  [][]string{{small, small}, {small}},
+		},
+		{
+			name:  "too big on its own",
+			texts: []string{small, big, small},
+			max:   8,
+			want:  [][]string{{small}, {big}, {small}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := groupTexts(tt.texts, tt.max); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groups = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
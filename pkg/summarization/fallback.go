@@ -0,0 +1,77 @@
+package summarization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/fallback"
+	"github.com/ahmadbasyouni10/videogpt/pkg/httpclient"
+)
+
+// Provider is a named summarization service in a fallback chain
+type Provider struct {
+	Name    string
+	Service Service
+}
+
+// FallbackService implements the Service interface over several providers,
+// trying them in order and skipping the ones whose circuit breaker is open
+type FallbackService struct {
+	Providers []Provider
+	Breakers  []*fallback.Breaker // one per provider, same order
+}
+
+// NewFallbackService creates a fallback chain, tried in the order given
+func NewFallbackService(providers ...Provider) *FallbackService {
+	breakers := make([]*fallback.Breaker, len(providers))
+	for i, provider := range providers {
+		breakers[i] = fallback.NewBreaker(provider.Name)
+	}
+	return &FallbackService{
+		Providers: providers,
+		Breakers:  breakers,
+	}
+}
+
+// Summarize summarizes with the first provider that succeeds
+func (s *FallbackService) Summarize(ctx context.Context, transcript *models.Transcript, opts Options) (*models.Summary, error) {
+	var failures []error
+	for i, provider := range s.Providers {
+		breaker := s.Breakers[i]
+		if !breaker.Allow() {
+			failures = append(failures, fmt.Errorf("%s: circuit open", provider.Name))
+			continue
+		}
+
+		summary, err := provider.Service.Summarize(ctx, transcript, opts)
+		if err == nil {
+			breaker.Success()
+			return summary, nil
+		}
+
+		// a cancelled request or a rejected prompt says nothing about the provider's health
+		switch {
+		case ctx.Err() != nil:
+			breaker.Release()
+			return nil, ctx.Err()
+		case errors.Is(err, httpclient.ErrInvalidInput):
+			breaker.Release()
+		default:
+			breaker.Failure(err)
+		}
+		fmt.Printf("Summarization provider %s failed, trying the next one: %v\n", provider.Name, err)
+		failures = append(failures, fmt.Errorf("%s: %w", provider.Name, err))
+	}
+	return nil, &fallback.ChainError{Errors: failures}
+}
+
+// Health returns the breaker state of every provider, in fallback order
+func (s *FallbackService) Health() []fallback.Health {
+	health := make([]fallback.Health, len(s.Breakers))
+	for i, breaker := range s.Breakers {
+		health[i] = breaker.Health()
+	}
+	return health
+}
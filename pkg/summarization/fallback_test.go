@@ -0,0 +1,118 @@
+package summarization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/fallback"
+	"github.com/ahmadbasyouni10/videogpt/pkg/httpclient"
+)
+
+// fakeService answers every request with err, or a summary naming the service
+type fakeService struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (s *fakeService) Summarize(ctx context.Context, transcript *models.Transcript, opts Options) (*models.Summary, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.Summary{Text: "from " + s.name}, nil
+}
+
+func TestFallbackService(t *testing.T) {
+	outage := errors.New("503 Service Unavailable")
+
+	tests := []struct {
+		name         string
+		errs         []error // one per provider, nil answers
+		open         []bool  // providers whose breaker is already open
+		want         string
+		wantCalls    []int
+		wantFailures []int // consecutive failures on each breaker afterwards
+	}{
+		{
+			name:         "first provider answers",
+			errs:         []error{nil, nil},
+			want:         "from openai",
+			wantCalls:    []int{1, 0},
+			wantFailures: []int{0, 0},
+		},
+		{
+			name:         "a failure moves down the chain",
+			errs:         []error{outage, nil},
+			want:         "from ollama",
+			wantCalls:    []int{1, 1},
+			wantFailures: []int{1, 0},
+		},
+		{
+			name:         "rejected input doesn't count as a failure",
+			errs:         []error{fmt.Errorf("prompt too long: %w", httpclient.ErrInvalidInput), nil},
+			want:         "from ollama",
+			wantCalls:    []int{1, 1},
+			wantFailures: []int{0, 0},
+		},
+		{
+			name:         "an open breaker is skipped",
+			errs:         []error{nil, nil},
+			open:         []bool{true, false},
+			want:         "from ollama",
+			wantCalls:    []int{0, 1},
+			wantFailures: []int{3, 0},
+		},
+		{
+			name:         "everyone failed",
+			errs:         []error{outage, outage},
+			wantCalls:    []int{1, 1},
+			wantFailures: []int{1, 1},
+		},
+	}
+
+	names := []string{"openai", "ollama"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			services := make([]*fakeService, len(tt.errs))
+			providers := make([]Provider, len(tt.errs))
+			for i, err := range tt.errs {
+				services[i] = &fakeService{name: names[i], err: err}
+				providers[i] = Provider{Name: names[i], Service: services[i]}
+			}
+			chain := NewFallbackService(providers...)
+			for i, open := range tt.open {
+				for j := 0; open && j < chain.Breakers[i].FailureThreshold; j++ {
+					chain.Breakers[i].Failure(outage)
+				}
+			}
+
+			summary, err := chain.Summarize(context.Background(), &models.Transcript{Text: "transcript"}, Options{})
+			if tt.want == "" {
+				if !errors.Is(err, fallback.ErrUnavailable) {
+					t.Fatalf("err = %v, want ErrUnavailable", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Summarize: %v", err)
+			} else if summary.Text != tt.want {
+				t.Errorf("summary = %q, want %q", summary.Text, tt.want)
+			}
+
+			var calls, failures []int
+			for i, service := range services {
+				calls = append(calls, service.calls)
+				failures = append(failures, chain.Breakers[i].Health().ConsecutiveFailures)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+			if !reflect.DeepEqual(failures, tt.wantFailures) {
+				t.Errorf("breaker failures = %v, want %v", failures, tt.wantFailures)
+			}
+		})
+	}
+}
@@ -1,129 +1,309 @@
 package summarization
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"net/http"
+	"strings"
 	"time"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/chat"
+	"github.com/ahmadbasyouni10/videogpt/pkg/parallel"
 )
 
 // Service defines the interface for summarization services
 type Service interface {
-	SummarizeText(text string) (string, error)
+	// Summarize summarizes a transcript of any length
+	Summarize(ctx context.Context, transcript *models.Transcript, opts Options) (*models.Summary, error)
+}
+
+// Options are the per-request settings for a summary
+// Model, Temperature and TopP override the template's, which override the service's
+// Style and Length shape the final summary, empty leaves templates that don't use
+// {{.Format}} to their own shape and gives the rest concise and medium
+type Options struct {
+	Template    *Template // nil for DefaultTemplate
+	Style       string    // one of Styles
+	Length      string    // one of Lengths
+	Model       string
+	Temperature *float64
+	TopP        *float64
+	Title       string // template variables about the video
+	Description string
 }
 
 // OpenAIService implements the Service interface using OpenAI's API
+// the HTTP side lives in the chat client, which is shared with translation
+// transcripts too long for one prompt are summarized map-reduce style, see Summarize
 type OpenAIService struct {
-	APIKey  string
-	Timeout time.Duration
+	Client            *chat.Client
+	Model             string
+	StructuredModel   string // model for the structured style when none is picked, it needs structured outputs
+	StructuredOutputs bool   // the provider supports json_schema response formats, otherwise plain JSON mode is used
+	Temperature       float64
+	ChunkTokens       int // transcript tokens per prompt, well under the model's context window
+	OverlapTokens     int // context repeated from the end of the previous chunk
+	Concurrency       int // chunk summaries in flight at once
 }
 
 // NewOpenAIService creates a new OpenAI summarization service
 func NewOpenAIService(apiKey string) *OpenAIService {
 	return &OpenAIService{
-		APIKey:  apiKey,
-		Timeout: 60 * time.Second, // Default timeout of 60 seconds
+		Client:            chat.NewClient(apiKey),
+		Model:             "gpt-3.5-turbo", // Using a cheaper model for cost-effectiveness
+		StructuredModel:   "gpt-4o-mini",   // the cheapest model with structured outputs
+		StructuredOutputs: true,
+		Temperature:       0.3,  // Lower temperature for more focused and consistent outputs
+		ChunkTokens:       6000, // leaves room for the instructions and the reply in a 16k context
+		OverlapTokens:     200,
+		Concurrency:       4,
 	}
 }
 
-// OpenAIRequest represents a request to OpenAI's API
-type OpenAIRequest struct {
-	Model       string        `json:"model"`
-	Messages    []ChatMessage `json:"messages"`
-	Temperature float64       `json:"temperature"`
-}
+// summaryRun is one Summarize call with its settings worked out
+type summaryRun struct {
+	service  *OpenAIService
+	template Template
+	data     PromptData // the variables shared by every prompt
+	request  chat.Request
 
-// ChatMessage represents a message in a chat conversation
-type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	structured *models.StructuredSummary // set by the final prompt of a structured summary
 }
 
-// OpenAIResponse represents the response from OpenAI's API
-type OpenAIResponse struct {
-	Choices []struct {
-		Message struct {
-			Content string `json:"content"`
-		} `json:"message"`
-	} `json:"choices"`
-	Error *struct {
-		Message string `json:"message"`
-	} `json:"error,omitempty"`
-}
+// Summarize summarizes the transcript in one prompt when it fits, otherwise it
+// summarizes chunks of it (map) and then merges those summaries until one is left (reduce)
+func (s *OpenAIService) Summarize(ctx context.Context, transcript *models.Transcript, opts Options) (*models.Summary, error) {
+	chunkTokens := s.ChunkTokens
+	if chunkTokens <= 0 {
+		chunkTokens = 6000
+	}
 
-// SummarizeText sends text to OpenAI's API for summarization
-func (s *OpenAIService) SummarizeText(text string) (string, error) {
-	if s.APIKey == "" {
-		return "", errors.New("OpenAI API key is required")
-	}
-
-	// Prepare the request
-	prompt := fmt.Sprintf("Please provide a concise summary of the following transcript. Focus on the main topics, key points, and important details:\n\n%s", text)
-
-	request := OpenAIRequest{
-		Model: "gpt-3.5-turbo", // Using a cheaper model for cost-effectiveness
-		Messages: []ChatMessage{
-			{
-				Role:    "system",
-				Content: "You are an assistant that summarizes video transcripts clearly and concisely.",
-			},
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
-		Temperature: 0.3, // Lower temperature for more focused and consistent outputs
+	chunks := chunkSegments(transcript.TextSegments(), chunkTokens, s.OverlapTokens)
+	if len(chunks) == 0 {
+		return nil, errors.New("transcript is empty")
 	}
 
-	// Convert request to JSON
-	jsonData, err := json.Marshal(request)
+	run, err := s.newRun(transcript, opts)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, err
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
+	var text string
+	if len(chunks) == 1 {
+		data := run.data
+		data.Transcript = chunks[0].text()
+		data.Final = true
+		text, err = run.complete(ctx, run.template.Prompt, data)
+	} else {
+		text, err = run.mapReduce(ctx, chunks, chunkTokens)
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+		return nil, err
+	}
+	if run.data.Style == StyleTweet {
+		text = fitTweet(text)
 	}
 
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.APIKey))
+	return &models.Summary{
+		VideoID:         transcript.VideoID,
+		Text:            text,
+		Structured:      run.structured,
+		Style:           run.data.Style,
+		Length:          run.data.Length,
+		Template:        run.template.ID,
+		TemplateVersion: run.template.Version,
+		Model:           run.request.Model,
+		Temperature:     run.request.Temperature,
+		TopP:            run.request.TopP,
+		Chunks:          len(chunks),
+		CreatedAt:       time.Now(),
+	}, nil
+}
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: s.Timeout,
+// newRun picks the template and sampling settings for a request
+func (s *OpenAIService) newRun(transcript *models.Transcript, opts Options) (*summaryRun, error) {
+	tmpl := DefaultTemplate
+	if opts.Template != nil {
+		tmpl = *opts.Template
+	}
+	if err := tmpl.Validate(); err != nil {
+		return nil, err
 	}
+	tmpl = tmpl.withDefaults()
 
-	// Send the request
-	resp, err := client.Do(req)
+	style, length, err := NormalizeStyle(opts.Style, opts.Length)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, err
+	}
+	// Prompt decides, a template's own Prompt paired with the default CombinePrompt is still its own shape
+	usesFormat := strings.Contains(tmpl.Prompt, ".Format")
+	requested := opts.Style != "" || opts.Length != ""
+	if requested {
+		tmpl.Prompt = withFormat(tmpl.Prompt)
+		tmpl.CombinePrompt = withFormat(tmpl.CombinePrompt)
+	}
+
+	request := chat.Request{
+		Model:       s.Model,
+		Temperature: s.Temperature,
+	}
+	if style == StyleStructured && s.StructuredModel != "" {
+		request.Model = s.StructuredModel
+	}
+	if tmpl.Model != "" {
+		request.Model = tmpl.Model
+	}
+	if opts.Model != "" {
+		request.Model = opts.Model
+	}
+	if tmpl.Temperature != nil {
+		request.Temperature = *tmpl.Temperature
+	}
+	if opts.Temperature != nil {
+		request.Temperature = *opts.Temperature
+	}
+	request.TopP = tmpl.TopP
+	if opts.TopP != nil {
+		request.TopP = opts.TopP
+	}
+
+	data := PromptData{
+		Title:       opts.Title,
+		Description: opts.Description,
+		Language:    transcript.Language,
+	}
+	// a template with its own shape and no style asked for is left alone
+	if usesFormat || requested {
+		data.Style = style
+		data.Length = length
+		data.Format = styleInstructions[style][length]
+	}
+	if transcript.Duration > 0 {
+		data.Duration = models.FormatTime(transcript.Duration)
 	}
-	defer resp.Body.Close()
 
-	// Check the response status
-	if resp.StatusCode != http.StatusOK {
-		var errorResponse OpenAIResponse
-		if err := json.NewDecoder(resp.Body).Decode(&errorResponse); err == nil && errorResponse.Error != nil {
-			return "", fmt.Errorf("API request failed: %s", errorResponse.Error.Message)
+	return &summaryRun{
+		service:  s,
+		template: tmpl,
+		data:     data,
+		request:  request,
+	}, nil
+}
+
+// withFormat adds the style instruction to a prompt that doesn't place it itself
+func withFormat(prompt string) string {
+	if strings.Contains(prompt, ".Format") {
+		return prompt
+	}
+	return prompt + "{{if .Final}}\n\n{{.Format}}{{end}}"
+}
+
+// mapReduce summarizes each chunk on its own, then merges the summaries
+func (r *summaryRun) mapReduce(ctx context.Context, chunks []chunk, chunkTokens int) (string, error) {
+	summaries := make([]string, len(chunks))
+	err := r.service.parallel(ctx, len(chunks), func(ctx context.Context, i int) error {
+		data := r.data
+		data.Transcript = chunks[i].text()
+		data.Part = i + 1
+		data.Parts = len(chunks)
+		data.Range = chunks[i].label()
+
+		summary, err := r.complete(ctx, r.template.ChunkPrompt, data)
+		if err != nil {
+			return fmt.Errorf("part %d: %w", i+1, err)
+		}
+
+		part := fmt.Sprintf("part %d of %d", i+1, len(chunks))
+		if data.Range != "" {
+			part += " (" + data.Range + ")"
 		}
-		return "", fmt.Errorf("API request failed with status %d", resp.StatusCode)
+		summaries[i] = fmt.Sprintf("[%s]\n%s", part, summary)
+		return nil
+	})
+	if err != nil {
+		return "", err
 	}
 
-	// Parse the response
-	var result OpenAIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+	return r.reduce(ctx, summaries, chunkTokens)
+}
+
+// reduce merges summaries in groups that fit one prompt, level by level,
+// until a single group is left for the final summary
+func (r *summaryRun) reduce(ctx context.Context, summaries []string, chunkTokens int) (string, error) {
+	for {
+		groups := groupTexts(summaries, chunkTokens)
+		if len(groups) == 1 {
+			data := r.data
+			data.Summaries = strings.Join(groups[0], "\n\n")
+			data.Final = true
+			return r.complete(ctx, r.template.CombinePrompt, data)
+		}
+
+		merged := make([]string, len(groups))
+		err := r.service.parallel(ctx, len(groups), func(ctx context.Context, i int) error {
+			data := r.data
+			data.Summaries = strings.Join(groups[i], "\n\n")
+			summary, err := r.complete(ctx, r.template.CombinePrompt, data)
+			if err != nil {
+				return fmt.Errorf("merging summaries: %w", err)
+			}
+			merged[i] = summary
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+
+		// every level has to shrink or we'd loop forever on summaries that don't get shorter
+		if len(merged) >= len(summaries) {
+			return "", errors.New("summaries are too long to merge")
+		}
+		summaries = merged
 	}
+}
 
-	// Check if we got any choices back
-	if len(result.Choices) == 0 {
-		return "", errors.New("no summary was generated")
+// complete renders one prompt of the template and sends it
+func (r *summaryRun) complete(ctx context.Context, prompt string, data PromptData) (string, error) {
+	system, err := render(r.template.System, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to render system prompt: %w", err)
 	}
+	user, err := render(prompt, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to render prompt: %w", err)
+	}
+
+	request := r.request
+	request.Messages = []chat.Message{
+		{
+			Role:    "system",
+			Content: system,
+		},
+		{
+			Role:    "user",
+			Content: user,
+		},
+	}
+
+	// the prompt that produces a structured summary answers in JSON, the abstract stands in as its text
+	if data.Final && r.data.Style == StyleStructured {
+		structured, err := r.completeStructured(ctx, request)
+		if err != nil {
+			return "", err
+		}
+		r.structured = structured
+		return structured.Abstract, nil
+	}
+
+	summary, err := r.service.Client.Complete(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(summary), nil
+}
 
-	return result.Choices[0].Message.Content, nil
+// parallel runs fn for 0..n-1 with at most Concurrency at once, stopping at the first error
+func (s *OpenAIService) parallel(ctx context.Context, n int, fn func(ctx context.Context, i int) error) error {
+	return parallel.Run(ctx, n, s.Concurrency, fn)
 }
@@ -0,0 +1,183 @@
+package summarization
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/chat"
+)
+
+// structuredAttempts is how many times the final structured prompt is tried before giving up on malformed output
+const structuredAttempts = 3
+
+// structuredSchema is the JSON schema for models.StructuredSummary, strict mode needs
+// every property required and no additional properties
+var structuredSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"title": {"type": "string"},
+		"abstract": {"type": "string"},
+		"key_points": {"type": "array", "items": {"type": "string"}},
+		"topics": {"type": "array", "items": {"type": "string"}},
+		"action_items": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"task": {"type": "string"},
+					"owner": {"type": "string"},
+					"due": {"type": "string"}
+				},
+				"required": ["task", "owner", "due"],
+				"additionalProperties": false
+			}
+		},
+		"decisions": {"type": "array", "items": {"type": "string"}},
+		"questions": {"type": "array", "items": {"type": "string"}},
+		"quotes": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"text": {"type": "string"},
+					"speaker": {"type": "string"}
+				},
+				"required": ["text", "speaker"],
+				"additionalProperties": false
+			}
+		}
+	},
+	"required": ["title", "abstract", "key_points", "topics", "action_items", "decisions", "questions", "quotes"],
+	"additionalProperties": false
+}`)
+
+// structuredFields lists the keys every structured summary must have, in schema order
+var structuredFields = []string{"title", "abstract", "key_points", "topics", "action_items", "decisions", "questions", "quotes"}
+
+// structuredFormat is the response format for the final structured prompt
+// providers without structured outputs get plain JSON mode and rely on the prompt and parseStructured
+func (s *OpenAIService) structuredFormat() *chat.ResponseFormat {
+	if !s.StructuredOutputs {
+		return &chat.ResponseFormat{Type: "json_object"}
+	}
+	return &chat.ResponseFormat{
+		Type: "json_schema",
+		JSONSchema: &chat.JSONSchema{
+			Name:   "video_summary",
+			Schema: structuredSchema,
+			Strict: true,
+		},
+	}
+}
+
+// completeStructured sends the final prompt in JSON mode and checks the reply against
+// models.StructuredSummary, asking again with the problem spelled out when it doesn't fit
+func (r *summaryRun) completeStructured(ctx context.Context, request chat.Request) (*models.StructuredSummary, error) {
+	request.ResponseFormat = r.service.structuredFormat()
+
+	var lastErr error
+	for attempt := 0; attempt < structuredAttempts; attempt++ {
+		content, err := r.service.Client.Complete(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		summary, err := parseStructured(content)
+		if err == nil {
+			return summary, nil
+		}
+		lastErr = err
+		fmt.Printf("Structured summary attempt %d was malformed: %v\n", attempt+1, err)
+
+		// show the model its reply and what was wrong with it
+		request.Messages = append(request.Messages,
+			chat.Message{Role: "assistant", Content: content},
+			chat.Message{Role: "user", Content: fmt.Sprintf("That reply was not valid: %v. Reply again with only the corrected JSON object.", err)},
+		)
+	}
+	return nil, fmt.Errorf("structured summary was malformed after %d attempts: %w", structuredAttempts, lastErr)
+}
+
+// parseStructured decodes and checks a structured summary
+// every field must be present and nothing else, blank list entries are dropped
+func parseStructured(content string) (*models.StructuredSummary, error) {
+	content = strings.TrimSpace(content)
+	// some models wrap JSON mode output in a code fence anyway
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimSpace(strings.TrimSuffix(content, "```"))
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(content), &fields); err != nil {
+		return nil, fmt.Errorf("reply is not a JSON object: %w", err)
+	}
+	var missing []string
+	for _, field := range structuredFields {
+		if _, ok := fields[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing fields %s", strings.Join(missing, ", "))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(content)))
+	decoder.DisallowUnknownFields()
+	var summary models.StructuredSummary
+	if err := decoder.Decode(&summary); err != nil {
+		return nil, fmt.Errorf("reply doesn't match the schema: %w", err)
+	}
+
+	summary.Title = strings.TrimSpace(summary.Title)
+	summary.Abstract = strings.TrimSpace(summary.Abstract)
+	if summary.Title == "" {
+		return nil, errors.New("title is empty")
+	}
+	if summary.Abstract == "" {
+		return nil, errors.New("abstract is empty")
+	}
+
+	summary.KeyPoints = cleanList(summary.KeyPoints)
+	summary.Topics = cleanList(summary.Topics)
+	summary.Decisions = cleanList(summary.Decisions)
+	summary.Questions = cleanList(summary.Questions)
+
+	actionItems := []models.ActionItem{}
+	for _, item := range summary.ActionItems {
+		item.Task = strings.TrimSpace(item.Task)
+		item.Owner = strings.TrimSpace(item.Owner)
+		item.Due = strings.TrimSpace(item.Due)
+		if item.Task != "" {
+			actionItems = append(actionItems, item)
+		}
+	}
+	summary.ActionItems = actionItems
+
+	quotes := []models.Quote{}
+	for _, quote := range summary.Quotes {
+		quote.Text = strings.TrimSpace(quote.Text)
+		quote.Speaker = strings.TrimSpace(quote.Speaker)
+		if quote.Text != "" {
+			quotes = append(quotes, quote)
+		}
+	}
+	summary.Quotes = quotes
+
+	return &summary, nil
+}
+
+// cleanList trims entries and drops blank ones, always returning a non-nil list
+func cleanList(items []string) []string {
+	cleaned := []string{}
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			cleaned = append(cleaned, item)
+		}
+	}
+	return cleaned
+}
@@ -0,0 +1,145 @@
+package summarization
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+)
+
+// validStructured is a reply that matches the schema, with some entries to clean up
+const validStructured = `{
+	"title": "  Weekly sync ",
+	"abstract": "The team planned the release.",
+	"key_points": ["Release moves to Friday", "  "],
+	"topics": ["release"],
+	"action_items": [
+		{"task": " Update the changelog ", "owner": "Alice", "due": ""},
+		{"task": "", "owner": "Bob", "due": "Monday"}
+	],
+	"decisions": [],
+	"questions": [],
+	"quotes": [{"text": "Ship it.", "speaker": ""}, {"text": " ", "speaker": "Bob"}]
+}`
+
+func TestParseStructured(t *testing.T) {
+	want := &models.StructuredSummary{
+		Title:       "Weekly sync",
+		Abstract:    "The team planned the release.",
+		KeyPoints:   []string{"Release moves to Friday"},
+		Topics:      []string{"release"},
+		ActionItems: []models.ActionItem{{Task: "Update the changelog", Owner: "Alice"}},
+		Decisions:   []string{},
+		Questions:   []string{},
+		Quotes:      []models.Quote{{Text: "Ship it."}},
+	}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "plain", content: validStructured},
+		{name: "code fence", content: "```json\n" + validStructured + "\n```"},
+		{name: "bare code fence", content: "```\n" + validStructured + "\n```"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStructured(tt.content)
+			if err != nil {
+				t.Fatalf("parseStructured: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("summary = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseStructuredErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "not json",
+			content: "Here is your summary: it went well.",
+			wantErr: "not a JSON object",
+		},
+		{
+			name:    "missing fields",
+			content: `{"title": "t", "abstract": "a", "key_points": [], "topics": []}`,
+			wantErr: "missing fields action_items, decisions, questions, quotes",
+		},
+		{
+			name:    "unknown field",
+			content: strings.Replace(validStructured, `"topics"`, `"mood": "good", "topics"`, 1),
+			wantErr: "doesn't match the schema",
+		},
+		{
+			name:    "wrong type",
+			content: strings.Replace(validStructured, `"topics": ["release"]`, `"topics": "release"`, 1),
+			wantErr: "doesn't match the schema",
+		},
+		{
+			name:    "blank title",
+			content: strings.Replace(validStructured, `"  Weekly sync "`, `" "`, 1),
+			wantErr: "title is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseStructured(tt.content)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStructuredSchemaMatchesFields(t *testing.T) {
+	var schema struct {
+		Required []string `json:"required"`
+	}
+	if err := json.Unmarshal(structuredSchema, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(schema.Required, structuredFields) {
+		t.Errorf("schema requires %v, structuredFields is %v", schema.Required, structuredFields)
+	}
+}
+
+func TestStructuredPromptShape(t *testing.T) {
+	// the shape in the prompt is all JSON mode providers have, so it has to list the same keys as the schema
+	shape := structuredRules[strings.Index(structuredRules, "{") : strings.LastIndex(structuredRules, "}")+1]
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(shape), &fields); err != nil {
+		t.Fatalf("shape in the prompt is not valid JSON: %v", err)
+	}
+	var keys []string
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	want := append([]string(nil), structuredFields...)
+	sort.Strings(want)
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("prompt shape has %v, want %v", keys, want)
+	}
+
+	var summary models.StructuredSummary
+	if err := json.Unmarshal([]byte(shape), &summary); err != nil {
+		t.Errorf("prompt shape doesn't decode into StructuredSummary: %v", err)
+	}
+
+	for length, instruction := range styleInstructions[StyleStructured] {
+		if !strings.Contains(instruction, shape) {
+			t.Errorf("%s structured instruction doesn't spell out the shape", length)
+		}
+	}
+}
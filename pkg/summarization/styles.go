@@ -0,0 +1,119 @@
+package summarization
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Summary styles, the shape of the final summary
+const (
+	StyleConcise    = "concise"    // a few paragraphs, the original summary
+	StyleTLDR       = "tldr"       // one or two sentences
+	StyleBullets    = "bullets"    // bullet points
+	StyleOutline    = "outline"    // detailed outline with headings
+	StyleExecutive  = "executive"  // executive brief: bottom line, decisions, risks, next steps
+	StyleTweet      = "tweet"      // fits in a tweet
+	StyleStructured = "structured" // JSON fields, see models.StructuredSummary
+)
+
+// Summary lengths, how much detail the style goes into
+const (
+	LengthShort  = "short"
+	LengthMedium = "medium"
+	LengthLong   = "long"
+)
+
+// Styles lists every style in the order they are offered
+var Styles = []string{StyleConcise, StyleTLDR, StyleBullets, StyleOutline, StyleExecutive, StyleTweet, StyleStructured}
+
+// Lengths lists every length from shortest to longest
+var Lengths = []string{LengthShort, LengthMedium, LengthLong}
+
+// tweetLimit is the most characters a tweet-style summary may have
+const tweetLimit = 280
+
+// structuredRules are added to every structured length's instruction
+// providers in plain JSON mode only have the prompt to go by, so it spells out the exact shape
+const structuredRules = " Reply with exactly this shape and no other keys: " +
+	`{"title": "", "abstract": "", "key_points": [""], "topics": [""], "action_items": [{"task": "", "owner": "", "due": ""}], ` +
+	`"decisions": [""], "questions": [""], "quotes": [{"text": "", "speaker": ""}]}.` +
+	" Use empty lists for anything the transcript doesn't have and empty strings for unknown owners, due dates and speakers. " +
+	"Quote the transcript word for word."
+
+// styleInstructions tells the model what shape to give the summary, per style and length
+var styleInstructions = map[string]map[string]string{
+	StyleConcise: {
+		LengthShort:  "Write a single short paragraph.",
+		LengthMedium: "Write two or three paragraphs.",
+		LengthLong:   "Write several paragraphs covering every major topic in order.",
+	},
+	StyleTLDR: {
+		LengthShort:  "Write a TL;DR of one sentence.",
+		LengthMedium: "Write a TL;DR of at most two sentences.",
+		LengthLong:   "Write a TL;DR of at most three sentences.",
+	},
+	StyleBullets: {
+		LengthShort:  "Write 3 to 5 bullet points, one line each, starting each with \"- \".",
+		LengthMedium: "Write 5 to 10 bullet points starting each with \"- \".",
+		LengthLong:   "Write 10 to 20 bullet points starting each with \"- \", nesting sub-points with two spaces where it helps.",
+	},
+	StyleOutline: {
+		LengthShort:  "Write an outline with a Markdown heading per main topic and a line or two under each.",
+		LengthMedium: "Write a detailed outline with a Markdown heading per topic and bullet points for the key points under each.",
+		LengthLong:   "Write a thorough outline with Markdown headings and subheadings following the transcript's structure, with bullet points for every key point, example and figure.",
+	},
+	StyleExecutive: {
+		LengthShort:  "Write an executive brief: the bottom line in one sentence, then the key decisions and next steps as short bullet points.",
+		LengthMedium: "Write an executive brief with the sections Bottom line, Key points, Decisions, Risks and Next steps, leaving out any section the transcript has nothing for.",
+		LengthLong:   "Write a full executive brief with the sections Bottom line, Context, Key points, Decisions, Risks, Open questions and Next steps, leaving out any section the transcript has nothing for.",
+	},
+	StyleTweet: {
+		LengthShort:  fmt.Sprintf("Write one tweet of at most %d characters, without hashtags.", tweetLimit/2),
+		LengthMedium: fmt.Sprintf("Write one tweet of at most %d characters.", tweetLimit),
+		LengthLong:   fmt.Sprintf("Write one tweet of at most %d characters, packing in as much as fits.", tweetLimit),
+	},
+	StyleStructured: {
+		LengthShort: "Reply with a JSON object with a title, a one-sentence abstract, at most 3 key_points and 3 topics, " +
+			"the action_items (task, owner, due), decisions and open questions, and at most 2 notable quotes (text, speaker)." + structuredRules,
+		LengthMedium: "Reply with a JSON object with a title, a one-paragraph abstract, 3 to 7 key_points, up to 5 topics, " +
+			"the action_items (task, owner, due), decisions and open questions, and up to 5 notable quotes (text, speaker)." + structuredRules,
+		LengthLong: "Reply with a JSON object with a title, an abstract of two or three paragraphs, up to 15 key_points, up to 10 topics, " +
+			"every action_item (task, owner, due), decision and open question, and up to 10 notable quotes (text, speaker)." + structuredRules,
+	},
+}
+
+// NormalizeStyle checks style and length, filling in concise and medium when they are empty
+func NormalizeStyle(style string, length string) (string, string, error) {
+	style = strings.ToLower(strings.TrimSpace(style))
+	length = strings.ToLower(strings.TrimSpace(length))
+	if style == "" {
+		style = StyleConcise
+	}
+	if length == "" {
+		length = LengthMedium
+	}
+
+	if _, ok := styleInstructions[style]; !ok {
+		return "", "", fmt.Errorf("unknown summary style %q, use one of %s", style, strings.Join(Styles, ", "))
+	}
+	if _, ok := styleInstructions[style][length]; !ok {
+		return "", "", fmt.Errorf("unknown summary length %q, use one of %s", length, strings.Join(Lengths, ", "))
+	}
+	return style, length, nil
+}
+
+// fitTweet cuts a tweet-style summary down to tweetLimit characters at a word boundary,
+// models are bad at counting characters so the instruction alone isn't enough
+func fitTweet(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= tweetLimit {
+		return text
+	}
+
+	cut := string(runes[:tweetLimit-1])
+	if i := strings.LastIndex(cut, " "); i > tweetLimit/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:-") + "…"
+}
@@ -0,0 +1,142 @@
+package summarization
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"text/template"
+)
+
+// templateIDPattern keeps template IDs safe to use in the storage path of the summaries made with them
+var templateIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Template is a versioned set of prompts written with text/template
+// Prompt is used when the whole transcript fits in one request, ChunkPrompt and
+// CombinePrompt are the map and reduce steps for long ones; empty prompts fall back to DefaultTemplate's
+// the ID and Version are recorded with every summary made from the template
+type Template struct {
+	ID            string   `json:"id"`
+	Version       string   `json:"version"`
+	Description   string   `json:"description,omitempty"`
+	Model         string   `json:"model,omitempty"` // overrides the service's model
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"top_p,omitempty"`
+	System        string   `json:"system,omitempty"`
+	Prompt        string   `json:"prompt,omitempty"`
+	ChunkPrompt   string   `json:"chunk_prompt,omitempty"`
+	CombinePrompt string   `json:"combine_prompt,omitempty"`
+}
+
+// PromptData is what the templates can use
+// Part, Parts and Range are only set for ChunkPrompt, Summaries only for CombinePrompt
+// Format is the instruction for the requested style and length, see styleInstructions
+type PromptData struct {
+	Title       string
+	Description string
+	Language    string
+	Duration    string // H:MM:SS or M:SS, empty when unknown
+	Style       string
+	Length      string
+	Format      string
+	Transcript  string
+	Part        int
+	Parts       int
+	Range       string // where the chunk sits in the recording, empty for untimed text
+	Summaries   string
+	Final       bool // set for the prompt that produces the summary itself, Prompt or the last combine step
+}
+
+// DefaultTemplate is the original prompt, shaped by the requested style and length
+var DefaultTemplate = Template{
+	ID:          "default",
+	Version:     "2",
+	Description: "Summary of the main topics, key points and important details in the requested style",
+	System:      "You are an assistant that summarizes video transcripts clearly and concisely.",
+	Prompt: "Please provide a summary of the following transcript" +
+		`{{if .Title}} of "{{.Title}}"{{end}}. Focus on the main topics, key points, and important details.{{with .Format}} {{.}}{{end}}` +
+		"\n\n{{.Transcript}}",
+	ChunkPrompt: "This is part {{.Part}} of {{.Parts}}{{if .Range}} ({{.Range}}){{end}} of a long transcript" +
+		`{{if .Title}} of "{{.Title}}"{{end}}. ` +
+		"Summarize this part, keeping the key points, decisions, names and numbers so the parts can be combined later:" +
+		"\n\n{{.Transcript}}",
+	CombinePrompt: "These are summaries of consecutive parts of one transcript, in order. " +
+		"{{if .Final}}Combine them into one summary of the whole transcript. Focus on the main topics, key points, and important details.{{with .Format}} {{.}}{{end}}" +
+		"{{else}}Merge them into one summary of this stretch, keeping the key points, decisions, names and numbers.{{end}}" +
+		"\n\n{{.Summaries}}",
+}
+
+// BuiltinTemplates are available to every workspace, keyed by ID
+var BuiltinTemplates = map[string]Template{
+	DefaultTemplate.ID: DefaultTemplate,
+	"meeting": {
+		ID:          "meeting",
+		Version:     "1",
+		Description: "Meeting notes with decisions and action items",
+		System:      "You are an assistant that writes clear, accurate meeting notes from transcripts.",
+		Prompt: `Write meeting notes for the following transcript{{if .Title}} of "{{.Title}}"{{end}}` +
+			"{{if .Description}} ({{.Description}}){{end}}. " +
+			"Start with a short overview, then list the decisions made and the action items with their owners where they are mentioned." +
+			"{{if .Language}} Write the notes in the transcript's language ({{.Language}}).{{end}}" +
+			"\n\n{{.Transcript}}",
+	},
+}
+
+// withDefaults fills empty prompts from DefaultTemplate
+func (t Template) withDefaults() Template {
+	if t.System == "" {
+		t.System = DefaultTemplate.System
+	}
+	if t.Prompt == "" {
+		t.Prompt = DefaultTemplate.Prompt
+	}
+	if t.ChunkPrompt == "" {
+		t.ChunkPrompt = DefaultTemplate.ChunkPrompt
+	}
+	if t.CombinePrompt == "" {
+		t.CombinePrompt = DefaultTemplate.CombinePrompt
+	}
+	return t
+}
+
+// Validate checks that the template has an ID and version and that every prompt parses and renders
+func (t Template) Validate() error {
+	if t.ID == "" {
+		return errors.New("template id is required")
+	}
+	if !templateIDPattern.MatchString(t.ID) {
+		return fmt.Errorf("template id %q may only contain letters, digits, - and _", t.ID)
+	}
+	if t.Version == "" {
+		return fmt.Errorf("template %q needs a version", t.ID)
+	}
+	if t.Temperature != nil && (*t.Temperature < 0 || *t.Temperature > 2) {
+		return fmt.Errorf("template %q: temperature must be between 0 and 2", t.ID)
+	}
+	if t.TopP != nil && (*t.TopP <= 0 || *t.TopP > 1) {
+		return fmt.Errorf("template %q: top_p must be between 0 and 1", t.ID)
+	}
+
+	t = t.withDefaults()
+	for name, text := range map[string]string{"system": t.System, "prompt": t.Prompt, "chunk_prompt": t.ChunkPrompt, "combine_prompt": t.CombinePrompt} {
+		for _, final := range []bool{false, true} {
+			if _, err := render(text, PromptData{Final: final}); err != nil {
+				return fmt.Errorf("template %q: %s: %w", t.ID, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// render executes one prompt template, a field PromptData doesn't have is an error
+func render(text string, data PromptData) (string, error) {
+	tmpl, err := template.New("prompt").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
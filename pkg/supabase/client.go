@@ -2,6 +2,7 @@ package supabase
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -102,3 +103,69 @@ func (c *Client) UploadFileFromPath(bucket string, path string, filePath string)
 	// Use the existing UploadFile method
 	return c.UploadFile(bucket, path, file, fileHeader)
 }
+
+// ErrNotFound is returned by DownloadFile when the object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// UploadBytes uploads raw bytes to Supabase storage, replacing any existing object
+// used for the JSON documents we keep next to each video (transcripts and so on)
+func (c *Client) UploadBytes(bucket string, path string, data []byte, contentType string) (string, error) {
+	url := fmt.Sprintf("%s/storage/v1/object/%s/%s", c.URL, bucket, path)
+	req, err := http.NewRequest("POST", url, bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+
+	// x-upsert lets us overwrite, e.g. when a transcript is regenerated
+	req.Header.Set("Authorization", "Bearer "+c.Key)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("x-upsert", "true")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error uploading file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		responseBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("error uploading file: status code %d, response: %s", resp.StatusCode, string(responseBody))
+	}
+
+	fileURL := fmt.Sprintf("%s/storage/v1/object/public/%s/%s", c.URL, bucket, path)
+	return fileURL, nil
+}
+
+// DownloadFile downloads an object from Supabase storage
+func (c *Client) DownloadFile(bucket string, path string) ([]byte, error) {
+	url := fmt.Sprintf("%s/storage/v1/object/%s/%s", c.URL, bucket, path)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Key)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+
+	// storage answers a missing object with 404, or 400 and a not_found error on older versions
+	if resp.StatusCode == http.StatusNotFound ||
+		(resp.StatusCode == http.StatusBadRequest && bytes.Contains(responseBody, []byte("not_found"))) {
+		return nil, fmt.Errorf("%w: %s/%s", ErrNotFound, bucket, path)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error downloading file: status code %d, response: %s", resp.StatusCode, string(responseBody))
+	}
+
+	return responseBody, nil
+}
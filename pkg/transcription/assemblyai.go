@@ -0,0 +1,184 @@
+package transcription
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/httpclient"
+)
+
+// AssemblyAIBaseURL is AssemblyAI's hosted API
+const AssemblyAIBaseURL = "https://api.assemblyai.com/v2"
+
+// AssemblyAIService implements the Service interface using AssemblyAI
+// transcription there is asynchronous: upload, create a job, then poll until it's done
+type AssemblyAIService struct {
+	APIKey       string
+	HTTP         *httpclient.Client // per HTTP request, the job itself is bounded by ctx
+	PollInterval time.Duration
+	Options      Options // BaseURL, Model (speech_model) and Language are used
+}
+
+// NewAssemblyAIService creates a new AssemblyAI transcription service
+func NewAssemblyAIService(apiKey string) *AssemblyAIService {
+	return &AssemblyAIService{
+		APIKey:       apiKey,
+		HTTP:         httpclient.New(5 * time.Minute),
+		PollInterval: 3 * time.Second,
+		Options: Options{
+			BaseURL: AssemblyAIBaseURL,
+		},
+	}
+}
+
+// assemblyAIJob is the transcript resource, both when creating and when polling it
+type assemblyAIJob struct {
+	ID            string  `json:"id"`
+	Status        string  `json:"status"` // queued, processing, completed or error
+	Error         string  `json:"error"`
+	Text          string  `json:"text"`
+	LanguageCode  string  `json:"language_code"`
+	AudioDuration float64 `json:"audio_duration"` // seconds
+	Words         []struct {
+		Text  string `json:"text"`
+		Start int64  `json:"start"` // milliseconds
+		End   int64  `json:"end"`
+	} `json:"words"`
+}
+
+// TranscribeAudio uploads the audio, starts a transcript job and waits for it
+func (s *AssemblyAIService) TranscribeAudio(ctx context.Context, audioPath string, opts Options) (*models.Transcript, error) {
+	opts = s.Options.Merge(opts)
+	if opts.BaseURL == "" {
+		opts.BaseURL = AssemblyAIBaseURL
+	}
+	if s.APIKey == "" && opts.BaseURL == AssemblyAIBaseURL {
+		return nil, errors.New("AssemblyAI API key is required")
+	}
+
+	// read it into memory so the upload can be sent again on a retry
+	audio, err := os.ReadFile(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio file: %w", err)
+	}
+
+	// Upload the audio, AssemblyAI hands back a private URL to transcribe from
+	var upload struct {
+		UploadURL string `json:"upload_url"`
+	}
+	if err := s.do(ctx, "POST", opts.BaseURL+"/upload", "application/octet-stream", audio, &upload); err != nil {
+		return nil, fmt.Errorf("failed to upload audio: %w", err)
+	}
+
+	// Create the transcript job
+	request := map[string]interface{}{
+		"audio_url": upload.UploadURL,
+	}
+	if opts.Model != "" {
+		request["speech_model"] = opts.Model
+	}
+	if opts.Language != "" {
+		request["language_code"] = opts.Language
+	} else {
+		request["language_detection"] = true
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var job assemblyAIJob
+	if err := s.do(ctx, "POST", opts.BaseURL+"/transcript", "application/json", body, &job); err != nil {
+		return nil, fmt.Errorf("failed to create transcript: %w", err)
+	}
+
+	// Poll until the job finishes, the context bounds how long we wait
+	pollInterval := s.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = 3 * time.Second
+	}
+	for job.Status != "completed" {
+		if job.Status == "error" {
+			return nil, fmt.Errorf("transcription failed: %s", job.Error)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+
+		if err := s.do(ctx, "GET", opts.BaseURL+"/transcript/"+job.ID, "", nil, &job); err != nil {
+			return nil, fmt.Errorf("failed to check transcript: %w", err)
+		}
+	}
+
+	transcript := job.toTranscript()
+	if transcript.Language == "" {
+		transcript.Language = opts.Language
+	}
+	return transcript, nil
+}
+
+// TranslateAudio is not something AssemblyAI offers
+func (s *AssemblyAIService) TranslateAudio(ctx context.Context, audioPath string, opts Options) (*models.Transcript, error) {
+	return nil, ErrTranslationUnsupported
+}
+
+// do sends one API request and decodes the JSON response into result
+func (s *AssemblyAIService) do(ctx context.Context, method string, url string, contentType string, body []byte, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if s.APIKey != "" {
+		req.Header.Set("Authorization", s.APIKey)
+	}
+
+	responseBody, err := s.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(responseBody, result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// toTranscript converts a completed job into our transcript model
+// AssemblyAI has no segments, only words in milliseconds, so sentences are rebuilt from the words
+func (j *assemblyAIJob) toTranscript() *models.Transcript {
+	transcript := &models.Transcript{
+		Language:  j.LanguageCode,
+		Duration:  j.AudioDuration,
+		Text:      strings.TrimSpace(j.Text),
+		Source:    "assemblyai",
+		CreatedAt: time.Now(),
+	}
+	for _, word := range j.Words {
+		transcript.Words = append(transcript.Words, models.Word{
+			Start: float64(word.Start) / 1000,
+			End:   float64(word.End) / 1000,
+			Word:  word.Text,
+		})
+	}
+	transcript.Segments = segmentsFromWords(transcript.Words)
+	return transcript
+}
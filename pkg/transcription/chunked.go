@@ -0,0 +1,76 @@
+package transcription
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/parallel"
+)
+
+// DefaultConcurrency is how many chunks are transcribed at once
+const DefaultConcurrency = 4
+
+// Chunk is one piece of a longer audio file
+// Offset is where the chunk starts in the original audio, in seconds
+type Chunk struct {
+	Path     string
+	Offset   float64
+	Duration float64
+}
+
+// TranscribeChunks transcribes the chunks in parallel and stitches the results back together in order
+// each chunk's timestamps are shifted by its offset so they line up with the original audio,
+// and the first failure cancels the chunks that are still running
+func TranscribeChunks(ctx context.Context, service Service, chunks []Chunk, opts Options, concurrency int) (*models.Transcript, error) {
+	return processChunks(ctx, chunks, concurrency, func(ctx context.Context, path string) (*models.Transcript, error) {
+		return service.TranscribeAudio(ctx, path, opts)
+	})
+}
+
+// TranslateChunks is TranscribeChunks for translation into English
+func TranslateChunks(ctx context.Context, service Service, chunks []Chunk, opts Options, concurrency int) (*models.Transcript, error) {
+	return processChunks(ctx, chunks, concurrency, func(ctx context.Context, path string) (*models.Transcript, error) {
+		return service.TranslateAudio(ctx, path, opts)
+	})
+}
+
+// processChunks runs fn over the chunks in parallel and stitches the results in order
+func processChunks(ctx context.Context, chunks []Chunk, concurrency int, fn func(ctx context.Context, path string) (*models.Transcript, error)) (*models.Transcript, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	results := make([]*models.Transcript, len(chunks))
+	err := parallel.Run(ctx, len(chunks), concurrency, func(ctx context.Context, i int) error {
+		transcript, err := fn(ctx, chunks[i].Path)
+		if err != nil {
+			return fmt.Errorf("chunk %d: %w", i, err)
+		}
+		results[i] = transcript
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stitched := &models.Transcript{
+		Segments:  []models.Segment{},
+		Source:    "whisper",
+		CreatedAt: time.Now(),
+	}
+	for i, chunk := range chunks {
+		stitched.Append(results[i], chunk.Offset)
+		if i == 0 {
+			stitched.Source = results[i].Source
+			stitched.SourceLanguage = results[i].SourceLanguage
+		}
+		// a chunk that came back without a duration still covers its slice of audio
+		if end := chunk.Offset + chunk.Duration; end > stitched.Duration {
+			stitched.Duration = end
+		}
+	}
+
+	return stitched, nil
+}
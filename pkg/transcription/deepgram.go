@@ -0,0 +1,189 @@
+package transcription
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/httpclient"
+)
+
+// DeepgramBaseURL is Deepgram's hosted API
+const DeepgramBaseURL = "https://api.deepgram.com/v1"
+
+// DeepgramService implements the Service interface using Deepgram's pre-recorded audio API
+// Deepgram only transcribes, TranslateAudio returns ErrTranslationUnsupported
+type DeepgramService struct {
+	APIKey  string
+	HTTP    *httpclient.Client
+	Options Options // BaseURL, Model and Language are used, the Whisper-only fields are ignored
+}
+
+// NewDeepgramService creates a new Deepgram transcription service
+func NewDeepgramService(apiKey string) *DeepgramService {
+	return &DeepgramService{
+		APIKey: apiKey,
+		HTTP:   httpclient.New(5 * time.Minute),
+		Options: Options{
+			BaseURL: DeepgramBaseURL,
+			Model:   "nova-2",
+		},
+	}
+}
+
+// deepgramResponse is the part of Deepgram's /listen response we use
+type deepgramResponse struct {
+	Metadata struct {
+		Duration float64 `json:"duration"`
+	} `json:"metadata"`
+	Results struct {
+		Channels []struct {
+			DetectedLanguage string `json:"detected_language"`
+			Alternatives     []struct {
+				Transcript string `json:"transcript"`
+				Words      []struct {
+					Word           string  `json:"word"`
+					PunctuatedWord string  `json:"punctuated_word"`
+					Start          float64 `json:"start"`
+					End            float64 `json:"end"`
+				} `json:"words"`
+			} `json:"alternatives"`
+		} `json:"channels"`
+		Utterances []struct {
+			Start      float64 `json:"start"`
+			End        float64 `json:"end"`
+			Transcript string  `json:"transcript"`
+		} `json:"utterances"`
+	} `json:"results"`
+}
+
+// TranscribeAudio sends the audio to Deepgram and normalizes the result into our transcript model
+func (s *DeepgramService) TranscribeAudio(ctx context.Context, audioPath string, opts Options) (*models.Transcript, error) {
+	opts = s.Options.Merge(opts)
+	if opts.BaseURL == "" {
+		opts.BaseURL = DeepgramBaseURL
+	}
+	if s.APIKey == "" && opts.BaseURL == DeepgramBaseURL {
+		return nil, errors.New("Deepgram API key is required")
+	}
+
+	// read it into memory so the body can be sent again on a retry
+	audio, err := os.ReadFile(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio file: %w", err)
+	}
+
+	// utterances give us sentence-sized segments, smart_format adds punctuation and casing
+	query := url.Values{}
+	query.Set("model", opts.Model)
+	query.Set("smart_format", "true")
+	query.Set("utterances", "true")
+	if opts.Language != "" {
+		query.Set("language", opts.Language)
+	} else {
+		query.Set("detect_language", "true")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", opts.BaseURL+"/listen?"+query.Encode(), bytes.NewReader(audio))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	// Deepgram takes the raw audio as the body and sniffs the format, the type is just a hint
+	req.Header.Set("Content-Type", audioContentType(audioPath))
+	if s.APIKey != "" {
+		req.Header.Set("Authorization", "Token "+s.APIKey)
+	}
+
+	responseBody, err := s.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var result deepgramResponse
+	if err := json.Unmarshal(responseBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	transcript := result.toTranscript()
+	if transcript.Language == "" {
+		transcript.Language = opts.Language
+	}
+	return transcript, nil
+}
+
+// TranslateAudio is not something Deepgram offers
+func (s *DeepgramService) TranslateAudio(ctx context.Context, audioPath string, opts Options) (*models.Transcript, error) {
+	return nil, ErrTranslationUnsupported
+}
+
+// toTranscript converts the first channel's best alternative into our transcript model
+func (r *deepgramResponse) toTranscript() *models.Transcript {
+	transcript := &models.Transcript{
+		Duration:  r.Metadata.Duration,
+		Source:    "deepgram",
+		CreatedAt: time.Now(),
+	}
+	if len(r.Results.Channels) == 0 || len(r.Results.Channels[0].Alternatives) == 0 {
+		return transcript
+	}
+
+	channel := r.Results.Channels[0]
+	alternative := channel.Alternatives[0]
+	transcript.Language = channel.DetectedLanguage
+	transcript.Text = strings.TrimSpace(alternative.Transcript)
+
+	for _, word := range alternative.Words {
+		text := word.PunctuatedWord
+		if text == "" {
+			text = word.Word
+		}
+		transcript.Words = append(transcript.Words, models.Word{
+			Start: word.Start,
+			End:   word.End,
+			Word:  text,
+		})
+	}
+
+	// utterances are only there when asked for, otherwise cut the words into sentences ourselves
+	if len(r.Results.Utterances) == 0 {
+		transcript.Segments = segmentsFromWords(transcript.Words)
+		return transcript
+	}
+	for _, utterance := range r.Results.Utterances {
+		transcript.Segments = append(transcript.Segments, models.Segment{
+			ID:    len(transcript.Segments),
+			Start: utterance.Start,
+			End:   utterance.End,
+			Text:  strings.TrimSpace(utterance.Transcript),
+		})
+	}
+	return transcript
+}
+
+// audioContentType guesses the MIME type from the file extension for providers that take raw audio
+func audioContentType(audioPath string) string {
+	switch ext := strings.ToLower(filepath.Ext(audioPath)); ext {
+	case ".mp3":
+		return "audio/mpeg"
+	case ".m4a":
+		return "audio/mp4"
+	case ".wav":
+		return "audio/wav"
+	default:
+		if contentType := mime.TypeByExtension(ext); contentType != "" {
+			return contentType
+		}
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,105 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/fallback"
+	"github.com/ahmadbasyouni10/videogpt/pkg/httpclient"
+)
+
+// Provider is a named transcription service in a fallback chain
+type Provider struct {
+	Name    string
+	Service Service
+}
+
+// FallbackService implements the Service interface over several providers
+// each request goes to the first provider whose circuit breaker is closed and
+// moves down the list when one fails, so an outage at one vendor isn't an outage for us
+type FallbackService struct {
+	Providers []Provider
+	Breakers  []*fallback.Breaker // one per provider, same order
+}
+
+// NewFallbackService creates a fallback chain, tried in the order given
+func NewFallbackService(providers ...Provider) *FallbackService {
+	breakers := make([]*fallback.Breaker, len(providers))
+	for i, provider := range providers {
+		breakers[i] = fallback.NewBreaker(provider.Name)
+	}
+	return &FallbackService{
+		Providers: providers,
+		Breakers:  breakers,
+	}
+}
+
+// TranscribeAudio transcribes with the first provider that succeeds
+func (s *FallbackService) TranscribeAudio(ctx context.Context, audioPath string, opts Options) (*models.Transcript, error) {
+	return s.try(ctx, func(service Service) (*models.Transcript, error) {
+		return service.TranscribeAudio(ctx, audioPath, opts)
+	})
+}
+
+// TranslateAudio translates with the first provider that succeeds, skipping the ones that can't translate
+func (s *FallbackService) TranslateAudio(ctx context.Context, audioPath string, opts Options) (*models.Transcript, error) {
+	return s.try(ctx, func(service Service) (*models.Transcript, error) {
+		return service.TranslateAudio(ctx, audioPath, opts)
+	})
+}
+
+// Health returns the breaker state of every provider, in fallback order
+func (s *FallbackService) Health() []fallback.Health {
+	health := make([]fallback.Health, len(s.Breakers))
+	for i, breaker := range s.Breakers {
+		health[i] = breaker.Health()
+	}
+	return health
+}
+
+// try runs fn against each provider in turn
+// unsupported features and cancelled requests don't count against a provider's breaker
+func (s *FallbackService) try(ctx context.Context, fn func(Service) (*models.Transcript, error)) (*models.Transcript, error) {
+	var failures []error
+	unsupported := 0
+
+	for i, provider := range s.Providers {
+		breaker := s.Breakers[i]
+		if !breaker.Allow() {
+			failures = append(failures, fmt.Errorf("%s: circuit open", provider.Name))
+			continue
+		}
+
+		transcript, err := fn(provider.Service)
+		if err == nil {
+			breaker.Success()
+			return transcript, nil
+		}
+
+		switch {
+		case ctx.Err() != nil:
+			breaker.Release()
+			return nil, ctx.Err()
+		case errors.Is(err, ErrTranslationUnsupported):
+			breaker.Release()
+			unsupported++
+			continue
+		case errors.Is(err, httpclient.ErrInvalidInput):
+			// the provider is fine, it just didn't like this audio; another one still might
+			breaker.Release()
+		default:
+			breaker.Failure(err)
+		}
+
+		fmt.Printf("Transcription provider %s failed, trying the next one: %v\n", provider.Name, err)
+		failures = append(failures, fmt.Errorf("%s: %w", provider.Name, err))
+	}
+
+	// nobody failed, they just can't do it
+	if unsupported > 0 && len(failures) == 0 {
+		return nil, ErrTranslationUnsupported
+	}
+	return nil, &fallback.ChainError{Errors: failures}
+}
@@ -0,0 +1,172 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/fallback"
+	"github.com/ahmadbasyouni10/videogpt/pkg/httpclient"
+)
+
+// fakeService answers every request with err, or a transcript naming the service
+type fakeService struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (s *fakeService) TranscribeAudio(ctx context.Context, audioPath string, opts Options) (*models.Transcript, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.Transcript{Text: "from " + s.name, Source: s.name}, nil
+}
+
+func (s *fakeService) TranslateAudio(ctx context.Context, audioPath string, opts Options) (*models.Transcript, error) {
+	return s.TranscribeAudio(ctx, audioPath, opts)
+}
+
+func TestFallbackService(t *testing.T) {
+	outage := errors.New("503 Service Unavailable")
+
+	tests := []struct {
+		name         string
+		errs         []error // one per provider, nil answers
+		open         []bool  // providers whose breaker is already open
+		wantSource   string
+		wantErr      error
+		wantCalls    []int
+		wantFailures []int // consecutive failures on each breaker afterwards
+	}{
+		{
+			name:         "first provider answers",
+			errs:         []error{nil, nil},
+			wantSource:   "whisper",
+			wantCalls:    []int{1, 0},
+			wantFailures: []int{0, 0},
+		},
+		{
+			name:         "a failure moves down the chain",
+			errs:         []error{outage, nil},
+			wantSource:   "deepgram",
+			wantCalls:    []int{1, 1},
+			wantFailures: []int{1, 0},
+		},
+		{
+			name:         "rejected input doesn't count as a failure",
+			errs:         []error{fmt.Errorf("file too large: %w", httpclient.ErrInvalidInput), nil},
+			wantSource:   "deepgram",
+			wantCalls:    []int{1, 1},
+			wantFailures: []int{0, 0},
+		},
+		{
+			name:         "an open breaker is skipped",
+			errs:         []error{nil, nil},
+			open:         []bool{true, false},
+			wantSource:   "deepgram",
+			wantCalls:    []int{0, 1},
+			wantFailures: []int{3, 0},
+		},
+		{
+			name:         "unsupported translation doesn't count as a failure",
+			errs:         []error{ErrTranslationUnsupported, nil},
+			wantSource:   "deepgram",
+			wantCalls:    []int{1, 1},
+			wantFailures: []int{0, 0},
+		},
+		{
+			name:         "nobody can translate",
+			errs:         []error{ErrTranslationUnsupported, ErrTranslationUnsupported},
+			wantErr:      ErrTranslationUnsupported,
+			wantCalls:    []int{1, 1},
+			wantFailures: []int{0, 0},
+		},
+		{
+			name:         "everyone failed",
+			errs:         []error{outage, outage},
+			wantErr:      fallback.ErrUnavailable,
+			wantCalls:    []int{1, 1},
+			wantFailures: []int{1, 1},
+		},
+	}
+
+	names := []string{"whisper", "deepgram"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			services := make([]*fakeService, len(tt.errs))
+			providers := make([]Provider, len(tt.errs))
+			for i, err := range tt.errs {
+				services[i] = &fakeService{name: names[i], err: err}
+				providers[i] = Provider{Name: names[i], Service: services[i]}
+			}
+			chain := NewFallbackService(providers...)
+			for i, open := range tt.open {
+				for j := 0; open && j < chain.Breakers[i].FailureThreshold; j++ {
+					chain.Breakers[i].Failure(outage)
+				}
+			}
+
+			transcript, err := chain.TranslateAudio(context.Background(), "talk.mp3", Options{})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("TranslateAudio: %v", err)
+			} else if transcript.Source != tt.wantSource {
+				t.Errorf("answered by %s, want %s", transcript.Source, tt.wantSource)
+			}
+
+			var calls, failures []int
+			for i, service := range services {
+				calls = append(calls, service.calls)
+				failures = append(failures, chain.Breakers[i].Health().ConsecutiveFailures)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+			if !reflect.DeepEqual(failures, tt.wantFailures) {
+				t.Errorf("breaker failures = %v, want %v", failures, tt.wantFailures)
+			}
+		})
+	}
+}
+
+func TestFallbackServiceCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	first := &fakeService{name: "whisper", err: context.Canceled}
+	second := &fakeService{name: "deepgram"}
+	chain := NewFallbackService(Provider{Name: "whisper", Service: first}, Provider{Name: "deepgram", Service: second})
+
+	cancel()
+	_, err := chain.TranscribeAudio(ctx, "talk.mp3", Options{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if second.calls != 0 {
+		t.Errorf("the next provider was tried after the request was cancelled")
+	}
+	if failures := chain.Breakers[0].Health().ConsecutiveFailures; failures != 0 {
+		t.Errorf("cancelled request counted as %d failures", failures)
+	}
+}
+
+func TestFallbackServiceErrorNamesProviders(t *testing.T) {
+	chain := NewFallbackService(
+		Provider{Name: "whisper", Service: &fakeService{err: errors.New("503 Service Unavailable")}},
+		Provider{Name: "deepgram", Service: &fakeService{err: errors.New("401 Unauthorized")}},
+	)
+
+	_, err := chain.TranscribeAudio(context.Background(), "talk.mp3", Options{})
+	for _, want := range []string{"whisper: 503 Service Unavailable", "deepgram: 401 Unauthorized"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v, want it to mention %q", err, want)
+		}
+	}
+}
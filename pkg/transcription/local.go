@@ -0,0 +1,263 @@
+package transcription
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/captions"
+	"github.com/ahmadbasyouni10/videogpt/pkg/ffmpeg"
+)
+
+// Local CLI flavours, they take different flags and write different files
+const (
+	FlavorWhisperCpp    = "whisper.cpp"    // whisper-cli / main from ggerganov/whisper.cpp
+	FlavorFasterWhisper = "faster-whisper" // whisper-ctranslate2, the faster-whisper CLI
+)
+
+// LocalService implements the Service interface with a whisper.cpp or faster-whisper binary
+// nothing leaves the machine, which is the point for customers that can't use the cloud
+type LocalService struct {
+	Binary    string // path or name of the CLI
+	ModelPath string // ggml model file for whisper.cpp, model name or directory for faster-whisper
+	Flavor    string
+	Threads   int
+	TempDir   string
+	Runner    ffmpeg.Runner // runs both ffmpeg and the CLI, swap in ffmpegtest.Runner to fake them
+}
+
+// NewLocalService creates a local transcription service for the given binary and model
+// tempDir holds the converted audio and the CLI's output, the system temp dir when empty
+func NewLocalService(binary string, modelPath string, flavor string, tempDir string) *LocalService {
+	if flavor == "" {
+		flavor = FlavorWhisperCpp
+	}
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+	return &LocalService{
+		Binary:    binary,
+		ModelPath: modelPath,
+		Flavor:    flavor,
+		Threads:   runtime.NumCPU(),
+		TempDir:   tempDir,
+		Runner:    ffmpeg.ExecRunner{},
+	}
+}
+
+// TranscribeAudio transcribes the audio in its original language
+func (s *LocalService) TranscribeAudio(ctx context.Context, audioPath string, opts Options) (*models.Transcript, error) {
+	return s.run(ctx, audioPath, opts, false)
+}
+
+// TranslateAudio transcribes the audio into English, both CLIs support Whisper's translate task
+func (s *LocalService) TranslateAudio(ctx context.Context, audioPath string, opts Options) (*models.Transcript, error) {
+	transcript, err := s.run(ctx, audioPath, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	transcript.SourceLanguage = transcript.Language
+	if transcript.SourceLanguage == "" || transcript.SourceLanguage == "en" {
+		transcript.SourceLanguage = opts.Language
+	}
+	transcript.Language = "en"
+	return transcript, nil
+}
+
+// run converts the audio to what the CLI expects, runs it and parses what it wrote
+// the context is passed down to both processes, so cancelling it kills them
+func (s *LocalService) run(ctx context.Context, audioPath string, opts Options, translate bool) (*models.Transcript, error) {
+	if s.ModelPath == "" {
+		return nil, errors.New("local transcription needs a model file")
+	}
+
+	workDir, err := os.MkdirTemp(s.TempDir, "whisper-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	// whisper.cpp only reads 16 kHz mono 16-bit WAV, faster-whisper is fine with it too
+	wavPath := filepath.Join(workDir, "audio.wav")
+	_, err = s.Runner.CombinedOutput(ctx, "ffmpeg",
+		"-y",
+		"-i", audioPath,
+		"-vn",
+		"-ac", "1",
+		"-ar", ffmpeg.SpeechSampleRate,
+		"-c:a", "pcm_s16le",
+		wavPath,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert audio to WAV: %w", err)
+	}
+
+	var args []string
+	switch s.Flavor {
+	case FlavorFasterWhisper:
+		args = s.fasterWhisperArgs(wavPath, workDir, opts, translate)
+	default:
+		args = s.whisperCppArgs(wavPath, filepath.Join(workDir, "audio"), opts, translate)
+	}
+
+	if _, err := s.Runner.CombinedOutput(ctx, s.Binary, args...); err != nil {
+		// a cancelled context kills the process, report that rather than the exit status
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%s failed: %w", filepath.Base(s.Binary), err)
+	}
+
+	transcript, err := readLocalOutput(workDir)
+	if err != nil {
+		return nil, err
+	}
+	if transcript.Language == "" {
+		transcript.Language = opts.Language
+	}
+	return transcript, nil
+}
+
+// whisperCppArgs builds the whisper.cpp flags, it writes <outputPrefix>.json and .srt
+func (s *LocalService) whisperCppArgs(wavPath string, outputPrefix string, opts Options, translate bool) []string {
+	language := opts.Language
+	if language == "" {
+		language = "auto"
+	}
+	args := []string{
+		"-m", s.ModelPath,
+		"-f", wavPath,
+		"-l", language,
+		"-oj", "-osrt",
+		"-of", outputPrefix,
+	}
+	if s.Threads > 0 {
+		args = append(args, "-t", strconv.Itoa(s.Threads))
+	}
+	if translate {
+		args = append(args, "-tr")
+	}
+	if prompt := opts.FullPrompt(); prompt != "" {
+		args = append(args, "--prompt", prompt)
+	}
+	if opts.Temperature != nil {
+		args = append(args, "-tp", strconv.FormatFloat(*opts.Temperature, 'f', -1, 64))
+	}
+	return args
+}
+
+// fasterWhisperArgs builds the whisper-ctranslate2 flags, it writes <name>.json and .srt into outputDir
+func (s *LocalService) fasterWhisperArgs(wavPath string, outputDir string, opts Options, translate bool) []string {
+	task := "transcribe"
+	if translate {
+		task = "translate"
+	}
+	args := []string{
+		wavPath,
+		"--model", s.ModelPath,
+		"--task", task,
+		"--output_dir", outputDir,
+		"--output_format", "all",
+		"--word_timestamps", "True",
+	}
+	if opts.Language != "" {
+		args = append(args, "--language", opts.Language)
+	}
+	if s.Threads > 0 {
+		args = append(args, "--threads", strconv.Itoa(s.Threads))
+	}
+	if prompt := opts.FullPrompt(); prompt != "" {
+		args = append(args, "--initial_prompt", prompt)
+	}
+	if opts.Temperature != nil {
+		args = append(args, "--temperature", strconv.FormatFloat(*opts.Temperature, 'f', -1, 64))
+	}
+	return args
+}
+
+// whisperCppOutput is the JSON file whisper.cpp writes with -oj
+type whisperCppOutput struct {
+	Result struct {
+		Language string `json:"language"`
+	} `json:"result"`
+	Transcription []struct {
+		Offsets struct {
+			From int64 `json:"from"` // milliseconds
+			To   int64 `json:"to"`
+		} `json:"offsets"`
+		Text string `json:"text"`
+	} `json:"transcription"`
+}
+
+// readLocalOutput parses the JSON the CLI wrote, falling back to its SRT
+// faster-whisper's JSON has the same shape as Whisper's verbose_json
+func readLocalOutput(workDir string) (*models.Transcript, error) {
+	if data, err := os.ReadFile(filepath.Join(workDir, "audio.json")); err == nil {
+		transcript, err := parseLocalJSON(data)
+		if err == nil {
+			return transcript, nil
+		}
+		fmt.Printf("Warning: could not parse local transcription JSON, trying SRT: %v\n", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(workDir, "audio.srt"))
+	if err != nil {
+		return nil, errors.New("local transcription produced no output")
+	}
+	transcript, err := captions.Parse(captions.FormatSRT, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse local transcription: %w", err)
+	}
+	transcript.Source = "local"
+	return transcript, nil
+}
+
+// parseLocalJSON reads either whisper.cpp's or faster-whisper's JSON output
+func parseLocalJSON(data []byte) (*models.Transcript, error) {
+	var cpp whisperCppOutput
+	if err := json.Unmarshal(data, &cpp); err == nil && cpp.Transcription != nil {
+		transcript := &models.Transcript{
+			Language:  cpp.Result.Language,
+			Source:    "local",
+			CreatedAt: time.Now(),
+		}
+		var texts []string
+		for _, item := range cpp.Transcription {
+			text := strings.TrimSpace(item.Text)
+			if text == "" {
+				continue
+			}
+			transcript.Segments = append(transcript.Segments, models.Segment{
+				ID:    len(transcript.Segments),
+				Start: float64(item.Offsets.From) / 1000,
+				End:   float64(item.Offsets.To) / 1000,
+				Text:  text,
+			})
+			texts = append(texts, text)
+		}
+		transcript.Text = strings.Join(texts, " ")
+		if n := len(transcript.Segments); n > 0 {
+			transcript.Duration = transcript.Segments[n-1].End
+		}
+		return transcript, nil
+	}
+
+	var result WhisperResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode output: %w", err)
+	}
+	transcript := result.toTranscript()
+	transcript.Source = "local"
+	if n := len(transcript.Segments); n > 0 && transcript.Duration == 0 {
+		transcript.Duration = transcript.Segments[n-1].End
+	}
+	return transcript, nil
+}
@@ -0,0 +1,257 @@
+package transcription
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+)
+
+// what each CLI writes for "Hello there." then a pause and "How are you?"
+const (
+	whisperCppJSON = `{
+		"result": {"language": "en"},
+		"transcription": [
+			{"timestamps": {"from": "00:00:00,000", "to": "00:00:00,800"}, "offsets": {"from": 0, "to": 800}, "text": " Hello there."},
+			{"timestamps": {"from": "00:00:01,500", "to": "00:00:02,000"}, "offsets": {"from": 1500, "to": 2000}, "text": " "},
+			{"timestamps": {"from": "00:00:03,000", "to": "00:00:03,900"}, "offsets": {"from": 3000, "to": 3900}, "text": " How are you?"}
+		]
+	}`
+
+	fasterWhisperJSON = `{
+		"text": " Hello there. How are you?",
+		"language": "en",
+		"segments": [
+			{"id": 0, "start": 0.0, "end": 0.8, "text": " Hello there.", "avg_logprob": -0.21, "no_speech_prob": 0.01},
+			{"id": 1, "start": 3.0, "end": 3.9, "text": " How are you?", "avg_logprob": -0.18, "no_speech_prob": 0.02}
+		]
+	}`
+
+	localSRT = "1\n00:00:00,000 --> 00:00:00,800\nHello there.\n\n2\n00:00:03,000 --> 00:00:03,900\nHow are you?\n"
+)
+
+func TestParseLocalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *models.Transcript
+		wantErr bool
+	}{
+		{
+			name: "whisper.cpp",
+			data: whisperCppJSON,
+			want: &models.Transcript{
+				Language: "en",
+				Duration: 3.9,
+				Text:     "Hello there. How are you?",
+				Segments: []models.Segment{
+					{ID: 0, Start: 0, End: 0.8, Text: "Hello there."},
+					{ID: 1, Start: 3, End: 3.9, Text: "How are you?"},
+				},
+				Source: "local",
+			},
+		},
+		{
+			name: "faster-whisper",
+			data: fasterWhisperJSON,
+			want: &models.Transcript{
+				Language: "en",
+				Duration: 3.9,
+				Text:     "Hello there. How are you?",
+				Segments: []models.Segment{
+					{ID: 0, Start: 0, End: 0.8, Text: "Hello there.", AvgLogprob: -0.21, NoSpeechProb: 0.01},
+					{ID: 1, Start: 3, End: 3.9, Text: "How are you?", AvgLogprob: -0.18, NoSpeechProb: 0.02},
+				},
+				Source: "local",
+			},
+		},
+		{
+			name:    "not json",
+			data:    "Hello there. How are you?",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLocalJSON([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.CreatedAt.IsZero() {
+				t.Error("CreatedAt is not set")
+			}
+			got.CreatedAt = time.Time{}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("transcript = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadLocalOutput(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string // written into the work directory
+		wantTexts []string
+		wantErr   string
+	}{
+		{
+			name:      "json",
+			files:     map[string]string{"audio.json": whisperCppJSON, "audio.srt": localSRT},
+			wantTexts: []string{"Hello there.", "How are you?"},
+		},
+		{
+			name:      "srt when there is no json",
+			files:     map[string]string{"audio.srt": localSRT},
+			wantTexts: []string{"Hello there.", "How are you?"},
+		},
+		{
+			name:      "srt when the json is broken",
+			files:     map[string]string{"audio.json": `{"transcription": [`, "audio.srt": localSRT},
+			wantTexts: []string{"Hello there.", "How are you?"},
+		},
+		{
+			name:    "nothing written",
+			files:   map[string]string{},
+			wantErr: "local transcription produced no output",
+		},
+		{
+			name:    "broken srt",
+			files:   map[string]string{"audio.srt": "Hello there."},
+			wantErr: "failed to parse local transcription",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workDir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(workDir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			transcript, err := readLocalOutput(workDir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readLocalOutput: %v", err)
+			}
+
+			var texts []string
+			for _, segment := range transcript.Segments {
+				texts = append(texts, segment.Text)
+			}
+			if !reflect.DeepEqual(texts, tt.wantTexts) {
+				t.Errorf("segments = %q, want %q", texts, tt.wantTexts)
+			}
+			if transcript.Source != "local" {
+				t.Errorf("source = %q, want local", transcript.Source)
+			}
+		})
+	}
+}
+
+func TestWhisperCppArgs(t *testing.T) {
+	temperature := 0.2
+
+	tests := []struct {
+		name      string
+		threads   int
+		opts      Options
+		translate bool
+		want      string
+	}{
+		{
+			name: "detects the language",
+			want: "-m ggml-base.bin -f audio.wav -l auto -oj -osrt -of work/audio",
+		},
+		{
+			name:      "everything",
+			threads:   4,
+			opts:      Options{Language: "de", Prompt: "A talk about databases.", Temperature: &temperature},
+			translate: true,
+			want:      "-m ggml-base.bin -f audio.wav -l de -oj -osrt -of work/audio -t 4 -tr --prompt A talk about databases. -tp 0.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &LocalService{ModelPath: "ggml-base.bin", Threads: tt.threads}
+			got := strings.Join(s.whisperCppArgs("audio.wav", "work/audio", tt.opts, tt.translate), " ")
+			if got != tt.want {
+				t.Errorf("args = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFasterWhisperArgs(t *testing.T) {
+	temperature := 0.2
+
+	tests := []struct {
+		name      string
+		threads   int
+		opts      Options
+		translate bool
+		want      string
+	}{
+		{
+			name: "detects the language",
+			want: "audio.wav --model small --task transcribe --output_dir work --output_format all --word_timestamps True",
+		},
+		{
+			name:      "everything",
+			threads:   4,
+			opts:      Options{Language: "de", Prompt: "A talk about databases.", Temperature: &temperature},
+			translate: true,
+			want: "audio.wav --model small --task translate --output_dir work --output_format all --word_timestamps True" +
+				" --language de --threads 4 --initial_prompt A talk about databases. --temperature 0.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &LocalService{ModelPath: "small", Threads: tt.threads}
+			got := strings.Join(s.fasterWhisperArgs("audio.wav", "work", tt.opts, tt.translate), " ")
+			if got != tt.want {
+				t.Errorf("args = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewLocalService(t *testing.T) {
+	tests := []struct {
+		name        string
+		tempDir     string
+		wantTempDir string
+	}{
+		{name: "configured temp dir", tempDir: "/data/tmp", wantTempDir: "/data/tmp"},
+		{name: "system temp dir", wantTempDir: os.TempDir()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewLocalService("whisper-cli", "ggml-base.bin", "", tt.tempDir)
+			if s.TempDir != tt.wantTempDir {
+				t.Errorf("TempDir = %q, want %q", s.TempDir, tt.wantTempDir)
+			}
+			if s.Flavor != FlavorWhisperCpp {
+				t.Errorf("Flavor = %q, want %q", s.Flavor, FlavorWhisperCpp)
+			}
+		})
+	}
+}
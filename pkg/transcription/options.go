@@ -0,0 +1,115 @@
+package transcription
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultBaseURL is OpenAI's API, point BaseURL elsewhere for self-hosted Whisper-compatible servers
+const DefaultBaseURL = "https://api.openai.com/v1"
+
+// Response formats the transcription endpoint understands
+// only verbose_json carries segment timings, the others are parsed as best we can
+const (
+	FormatVerboseJSON = "verbose_json"
+	FormatJSON        = "json"
+	FormatText        = "text"
+	FormatSRT         = "srt"
+	FormatVTT         = "vtt"
+)
+
+// Options are the knobs for a transcription request
+// services hold global defaults and callers can override any field per request,
+// zero values mean "use the default"
+type Options struct {
+	BaseURL        string   `json:"base_url,omitempty"`
+	Model          string   `json:"model,omitempty"`
+	Language       string   `json:"language,omitempty"` // ISO-639-1 source language, empty to auto-detect
+	Temperature    *float64 `json:"temperature,omitempty"`
+	ResponseFormat string   `json:"response_format,omitempty"`
+	Prompt         string   `json:"prompt,omitempty"`     // vocabulary and style hints
+	Vocabulary     []string `json:"vocabulary,omitempty"` // terms listed after the merged prompt, e.g. a workspace glossary
+}
+
+// DefaultOptions returns the settings that match the original hard-coded behaviour
+func DefaultOptions() Options {
+	return Options{
+		BaseURL:        DefaultBaseURL,
+		Model:          "whisper-1",
+		ResponseFormat: FormatVerboseJSON,
+	}
+}
+
+// Merge returns o with every non-zero field of overrides applied on top
+func (o Options) Merge(overrides Options) Options {
+	if overrides.BaseURL != "" {
+		o.BaseURL = overrides.BaseURL
+	}
+	if overrides.Model != "" {
+		o.Model = overrides.Model
+	}
+	if overrides.Language != "" {
+		o.Language = overrides.Language
+	}
+	if overrides.Temperature != nil {
+		o.Temperature = overrides.Temperature
+	}
+	if overrides.ResponseFormat != "" {
+		o.ResponseFormat = overrides.ResponseFormat
+	}
+	if overrides.Prompt != "" {
+		o.Prompt = overrides.Prompt
+	}
+	if len(overrides.Vocabulary) > 0 {
+		o.Vocabulary = overrides.Vocabulary
+	}
+	return o
+}
+
+// maxPromptChars keeps the prompt inside Whisper's limit (224 tokens, ~4 chars each)
+const maxPromptChars = 800
+
+// FullPrompt is the prompt to send, the merged Prompt followed by the Vocabulary
+// Whisper copies the style and spelling of the prompt, so listing the terms is enough;
+// terms that don't fit in the limit are left out
+func (o Options) FullPrompt() string {
+	var terms []string
+	length := len(o.Prompt) + len("Vocabulary: .") + 1
+	for _, term := range o.Vocabulary {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		if length+len(term)+2 > maxPromptChars {
+			break
+		}
+		terms = append(terms, term)
+		length += len(term) + 2
+	}
+	if len(terms) == 0 {
+		return o.Prompt
+	}
+
+	vocabulary := "Vocabulary: " + strings.Join(terms, ", ") + "."
+	if o.Prompt == "" {
+		return vocabulary
+	}
+	return o.Prompt + " " + vocabulary
+}
+
+// OptionsFromEnv reads the global overrides from WHISPER_* environment variables
+// unset variables are left zero so the result can be merged over DefaultOptions
+func OptionsFromEnv() Options {
+	opts := Options{
+		BaseURL:        strings.TrimRight(os.Getenv("WHISPER_BASE_URL"), "/"),
+		Model:          os.Getenv("WHISPER_MODEL"),
+		Language:       os.Getenv("WHISPER_LANGUAGE"),
+		ResponseFormat: os.Getenv("WHISPER_RESPONSE_FORMAT"),
+		Prompt:         os.Getenv("WHISPER_PROMPT"),
+	}
+	if value, err := strconv.ParseFloat(os.Getenv("WHISPER_TEMPERATURE"), 64); err == nil {
+		opts.Temperature = &value
+	}
+	return opts
+}
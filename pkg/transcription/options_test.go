@@ -0,0 +1,66 @@
+package transcription
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFullPrompt(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "prompt only",
+			opts: Options{Prompt: "A talk about databases."},
+			want: "A talk about databases.",
+		},
+		{
+			name: "vocabulary only",
+			opts: Options{Vocabulary: []string{"PostgreSQL", " ", "gRPC"}},
+			want: "Vocabulary: PostgreSQL, gRPC.",
+		},
+		{
+			name: "prompt keeps its place before the vocabulary",
+			opts: Options{Prompt: "A talk about databases.", Vocabulary: []string{"PostgreSQL"}},
+			want: "A talk about databases. Vocabulary: PostgreSQL.",
+		},
+		{
+			name: "empty",
+			opts: Options{},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.FullPrompt(); got != tt.want {
+				t.Errorf("FullPrompt = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFullPromptLimit(t *testing.T) {
+	opts := Options{Prompt: strings.Repeat("p", 700)}
+	for i := 0; i < 50; i++ {
+		opts.Vocabulary = append(opts.Vocabulary, "term")
+	}
+
+	prompt := opts.FullPrompt()
+	if len(prompt) > maxPromptChars {
+		t.Errorf("prompt is %d chars, want at most %d", len(prompt), maxPromptChars)
+	}
+	if !strings.HasPrefix(prompt, opts.Prompt+" Vocabulary: term") {
+		t.Errorf("prompt lost the configured prompt or all of the vocabulary: %q", prompt)
+	}
+}
+
+func TestMergeKeepsPromptAndVocabulary(t *testing.T) {
+	base := Options{Prompt: "from WHISPER_PROMPT"}
+	merged := base.Merge(Options{Vocabulary: []string{"gRPC"}})
+	if got := merged.FullPrompt(); got != "from WHISPER_PROMPT Vocabulary: gRPC." {
+		t.Errorf("FullPrompt = %q", got)
+	}
+}
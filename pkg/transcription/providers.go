@@ -0,0 +1,188 @@
+package transcription
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/ratelimit"
+)
+
+// Provider names accepted in Config.Provider / TRANSCRIPTION_PROVIDER
+const (
+	ProviderOpenAI           = "openai"
+	ProviderOpenAICompatible = "openai-compatible"
+	ProviderDeepgram         = "deepgram"
+	ProviderAssemblyAI       = "assemblyai"
+	ProviderWhisperCpp       = FlavorWhisperCpp    // local binary, see LocalService
+	ProviderFasterWhisper    = FlavorFasterWhisper // local binary, see LocalService
+)
+
+// Config picks and sets up the transcription provider
+// empty fields fall back to the provider's defaults
+type Config struct {
+	Provider string
+	APIKey   string
+	BaseURL  string
+	Model    string // model name, or the model file for the local providers
+	Binary   string // CLI for the local providers
+	TempDir  string // scratch space for the local providers, the processor's TEMP_DIR
+	// Limiter is shared by everything using the same API key, nil for no client-side limit
+	Limiter *ratelimit.Limiter
+}
+
+// ConfigFromEnv reads the provider settings from TRANSCRIPTION_* environment variables
+// openaiAPIKey is used when TRANSCRIPTION_API_KEY isn't set and the provider is OpenAI
+func ConfigFromEnv(openaiAPIKey string) Config {
+	config := Config{
+		Provider: strings.ToLower(os.Getenv("TRANSCRIPTION_PROVIDER")),
+		APIKey:   os.Getenv("TRANSCRIPTION_API_KEY"),
+		BaseURL:  strings.TrimRight(os.Getenv("TRANSCRIPTION_BASE_URL"), "/"),
+		Model:    os.Getenv("TRANSCRIPTION_MODEL"),
+		Binary:   os.Getenv("TRANSCRIPTION_BINARY"),
+	}
+	if config.Provider == "" {
+		config.Provider = ProviderOpenAI
+	}
+	if config.APIKey == "" && config.Provider == ProviderOpenAI {
+		config.APIKey = openaiAPIKey
+	}
+	return config
+}
+
+// FallbackConfigsFromEnv reads the providers to fall back to, in order, from TRANSCRIPTION_FALLBACK
+// (e.g. "deepgram,whisper.cpp"); each one takes its settings from <NAME>_API_KEY, <NAME>_BASE_URL,
+// <NAME>_MODEL and <NAME>_BINARY, with NAME the provider in upper case (DEEPGRAM, WHISPER_CPP, ...)
+func FallbackConfigsFromEnv(openaiAPIKey string) []Config {
+	var configs []Config
+	for _, provider := range strings.Split(os.Getenv("TRANSCRIPTION_FALLBACK"), ",") {
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		if provider == "" {
+			continue
+		}
+
+		prefix := strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(provider)) + "_"
+		config := Config{
+			Provider: provider,
+			APIKey:   os.Getenv(prefix + "API_KEY"),
+			BaseURL:  strings.TrimRight(os.Getenv(prefix+"BASE_URL"), "/"),
+			Model:    os.Getenv(prefix + "MODEL"),
+			Binary:   os.Getenv(prefix + "BINARY"),
+		}
+		if config.APIKey == "" && provider == ProviderOpenAI {
+			config.APIKey = openaiAPIKey
+		}
+		configs = append(configs, config)
+	}
+	return configs
+}
+
+// NewService creates the transcription service named by the config
+// the Whisper-based providers also pick up the WHISPER_* overrides, see OptionsFromEnv
+func NewService(config Config) (Service, error) {
+	overrides := Options{BaseURL: config.BaseURL, Model: config.Model}
+
+	switch config.Provider {
+	case ProviderOpenAI, "":
+		service := NewWhisperService(config.APIKey)
+		service.Options = service.Options.Merge(OptionsFromEnv()).Merge(overrides)
+		service.HTTP.Limiter = config.Limiter
+		return service, nil
+
+	case ProviderOpenAICompatible:
+		service := NewOpenAICompatibleService(config.BaseURL, config.APIKey)
+		service.Options = service.Options.Merge(OptionsFromEnv()).Merge(overrides)
+		service.HTTP.Limiter = config.Limiter
+		if service.Options.BaseURL == DefaultBaseURL {
+			return nil, fmt.Errorf("%s provider needs a base URL", config.Provider)
+		}
+		return service, nil
+
+	case ProviderDeepgram:
+		service := NewDeepgramService(config.APIKey)
+		service.Options = service.Options.Merge(overrides)
+		service.HTTP.Limiter = config.Limiter
+		return service, nil
+
+	case ProviderAssemblyAI:
+		service := NewAssemblyAIService(config.APIKey)
+		service.Options = service.Options.Merge(overrides)
+		service.HTTP.Limiter = config.Limiter
+		return service, nil
+
+	case ProviderWhisperCpp, ProviderFasterWhisper:
+		if config.Model == "" {
+			return nil, fmt.Errorf("%s provider needs a model, set TRANSCRIPTION_MODEL", config.Provider)
+		}
+		binary := config.Binary
+		if binary == "" {
+			binary = "whisper-cli"
+			if config.Provider == ProviderFasterWhisper {
+				binary = "whisper-ctranslate2"
+			}
+		}
+		if _, err := exec.LookPath(binary); err != nil {
+			return nil, fmt.Errorf("%s binary %q not found: %w", config.Provider, binary, err)
+		}
+		return NewLocalService(binary, config.Model, config.Provider, config.TempDir), nil
+	}
+	return nil, fmt.Errorf("unknown transcription provider %q", config.Provider)
+}
+
+// NewOpenAICompatibleService creates a Whisper service for a server that implements
+// OpenAI's audio API (faster-whisper-server, LocalAI, Groq, ...)
+// these servers often don't support verbose_json word timings, set WHISPER_RESPONSE_FORMAT if needed
+func NewOpenAICompatibleService(baseURL string, apiKey string) *WhisperService {
+	service := NewWhisperService(apiKey)
+	service.Options.BaseURL = strings.TrimRight(baseURL, "/")
+	if service.Options.BaseURL == "" {
+		service.Options.BaseURL = DefaultBaseURL
+	}
+	return service
+}
+
+// maxSegmentWords keeps segments built from words to a caption-friendly size
+const maxSegmentWords = 30
+
+// segmentsFromWords groups word timings into sentence-sized segments for providers
+// that only return words; a segment ends at sentence punctuation, a long pause, or maxSegmentWords
+func segmentsFromWords(words []models.Word) []models.Segment {
+	var segments []models.Segment
+	var current []string
+	start, end := 0.0, 0.0
+
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		segments = append(segments, models.Segment{
+			ID:    len(segments),
+			Start: start,
+			End:   end,
+			Text:  strings.Join(current, " "),
+		})
+		current = nil
+	}
+
+	for i, word := range words {
+		text := strings.TrimSpace(word.Word)
+		if text == "" {
+			continue
+		}
+		if len(current) == 0 {
+			start = word.Start
+		}
+		current = append(current, text)
+		end = word.End
+
+		sentenceEnd := strings.ContainsAny(text[len(text)-1:], ".?!")
+		pause := i+1 < len(words) && words[i+1].Start-word.End > 1.0
+		if sentenceEnd || pause || len(current) >= maxSegmentWords {
+			flush()
+		}
+	}
+	flush()
+	return segments
+}
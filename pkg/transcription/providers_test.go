@@ -0,0 +1,392 @@
+package transcription
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+)
+
+// recorded responses from each provider for the same six seconds of audio:
+// "Hello there." then a pause and "How are you?"
+const (
+	deepgramPayload = `{
+		"metadata": {"request_id": "5b1a3c9e", "duration": 6.2, "channels": 1},
+		"results": {
+			"channels": [{
+				"detected_language": "en",
+				"language_confidence": 0.99,
+				"alternatives": [{
+					"transcript": "Hello there. How are you?",
+					"confidence": 0.98,
+					"words": [
+						{"word": "hello", "start": 0.08, "end": 0.4, "confidence": 0.99, "punctuated_word": "Hello"},
+						{"word": "there", "start": 0.4, "end": 0.8, "confidence": 0.98, "punctuated_word": "there."},
+						{"word": "how", "start": 3.1, "end": 3.3, "confidence": 0.99, "punctuated_word": "How"},
+						{"word": "are", "start": 3.3, "end": 3.5, "confidence": 0.99, "punctuated_word": "are"},
+						{"word": "you", "start": 3.5, "end": 3.9, "confidence": 0.97, "punctuated_word": "you?"}
+					]
+				}]
+			}],
+			"utterances": [
+				{"id": "u0", "channel": 0, "start": 0.08, "end": 0.8, "confidence": 0.98, "transcript": "Hello there."},
+				{"id": "u1", "channel": 0, "start": 3.1, "end": 3.9, "confidence": 0.98, "transcript": "How are you?"}
+			]
+		}
+	}`
+
+	assemblyAIPayload = `{
+		"id": "6ph9b3xk2e",
+		"status": "completed",
+		"language_code": "en_us",
+		"audio_duration": 6.2,
+		"text": "Hello there. How are you?",
+		"words": [
+			{"text": "Hello", "start": 80, "end": 400, "confidence": 0.99, "speaker": null},
+			{"text": "there.", "start": 400, "end": 800, "confidence": 0.98, "speaker": null},
+			{"text": "How", "start": 3100, "end": 3300, "confidence": 0.99, "speaker": null},
+			{"text": "are", "start": 3300, "end": 3500, "confidence": 0.99, "speaker": null},
+			{"text": "you?", "start": 3500, "end": 3900, "confidence": 0.97, "speaker": null}
+		]
+	}`
+
+	verboseJSONPayload = `{
+		"task": "transcribe",
+		"language": "english",
+		"duration": 6.2,
+		"text": "Hello there. How are you?",
+		"segments": [
+			{"id": 0, "seek": 0, "start": 0.0, "end": 0.8, "text": " Hello there.", "tokens": [50364, 2425, 456, 13], "temperature": 0.0, "avg_logprob": -0.21, "compression_ratio": 0.8, "no_speech_prob": 0.01},
+			{"id": 1, "seek": 0, "start": 3.0, "end": 3.9, "text": " How are you?", "tokens": [50514, 1012, 366, 291, 30], "temperature": 0.0, "avg_logprob": -0.18, "compression_ratio": 0.8, "no_speech_prob": 0.02}
+		],
+		"words": [
+			{"word": "Hello", "start": 0.08, "end": 0.4},
+			{"word": "there", "start": 0.4, "end": 0.8},
+			{"word": "How", "start": 3.1, "end": 3.3},
+			{"word": "are", "start": 3.3, "end": 3.5},
+			{"word": "you", "start": 3.5, "end": 3.9}
+		]
+	}`
+
+	// diarizationPayload is a pyannote wrapper's answer, not sorted by time
+	diarizationPayload = `{"segments": [
+		{"speaker": "SPEAKER_01", "start": 2.6, "end": 4.1},
+		{"speaker": "SPEAKER_00", "start": 0.0, "end": 1.2}
+	]}`
+)
+
+// writeAudio creates a small stand-in audio file for the upload
+func writeAudio(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "talk.mp3")
+	if err := os.WriteFile(path, []byte("ID3 not really audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// formFields reads the non-file fields of a multipart request
+func formFields(t *testing.T, r *http.Request) map[string][]string {
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		t.Errorf("request is not multipart: %v", err)
+		return nil
+	}
+	return r.MultipartForm.Value
+}
+
+func TestProviders(t *testing.T) {
+	sentences := []models.Segment{
+		{ID: 0, Start: 0.08, End: 0.8, Text: "Hello there."},
+		{ID: 1, Start: 3.1, End: 3.9, Text: "How are you?"},
+	}
+	punctuatedWords := []models.Word{
+		{Start: 0.08, End: 0.4, Word: "Hello"},
+		{Start: 0.4, End: 0.8, Word: "there."},
+		{Start: 3.1, End: 3.3, Word: "How"},
+		{Start: 3.3, End: 3.5, Word: "are"},
+		{Start: 3.5, End: 3.9, Word: "you?"},
+	}
+
+	tests := []struct {
+		name       string
+		handler    func(t *testing.T) http.HandlerFunc
+		newService func(baseURL string) Service
+		opts       Options
+		want       *models.Transcript
+	}{
+		{
+			name: "deepgram",
+			handler: func(t *testing.T) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Path != "/listen" || r.URL.Query().Get("detect_language") != "true" || r.URL.Query().Get("utterances") != "true" {
+						t.Errorf("unexpected request %s", r.URL)
+					}
+					if r.Header.Get("Authorization") != "Token dg-key" || r.Header.Get("Content-Type") != "audio/mpeg" {
+						t.Errorf("unexpected headers %v", r.Header)
+					}
+					io.WriteString(w, deepgramPayload)
+				}
+			},
+			newService: func(baseURL string) Service {
+				service := NewDeepgramService("dg-key")
+				service.Options.BaseURL = baseURL
+				return service
+			},
+			want: &models.Transcript{
+				Language: "en",
+				Duration: 6.2,
+				Text:     "Hello there. How are you?",
+				Segments: sentences,
+				Words:    punctuatedWords,
+				Source:   "deepgram",
+			},
+		},
+		{
+			name: "deepgram without utterances",
+			handler: func(t *testing.T) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Query().Get("language") != "en" || r.URL.Query().Has("detect_language") {
+						t.Errorf("language was not passed on: %s", r.URL)
+					}
+					var payload map[string]interface{}
+					json.Unmarshal([]byte(deepgramPayload), &payload)
+					delete(payload["results"].(map[string]interface{}), "utterances")
+					delete(payload["results"].(map[string]interface{})["channels"].([]interface{})[0].(map[string]interface{}), "detected_language")
+					json.NewEncoder(w).Encode(payload)
+				}
+			},
+			newService: func(baseURL string) Service {
+				service := NewDeepgramService("dg-key")
+				service.Options.BaseURL = baseURL
+				return service
+			},
+			opts: Options{Language: "en"},
+			want: &models.Transcript{
+				Language: "en",
+				Duration: 6.2,
+				Text:     "Hello there. How are you?",
+				Segments: sentences,
+				Words:    punctuatedWords,
+				Source:   "deepgram",
+			},
+		},
+		{
+			name: "assemblyai",
+			handler: func(t *testing.T) http.HandlerFunc {
+				var mu sync.Mutex
+				polls := 0
+				return func(w http.ResponseWriter, r *http.Request) {
+					if r.Header.Get("Authorization") != "aai-key" {
+						t.Errorf("unexpected Authorization %q", r.Header.Get("Authorization"))
+					}
+					switch {
+					case r.Method == "POST" && r.URL.Path == "/upload":
+						io.WriteString(w, `{"upload_url": "https://cdn.assemblyai.com/upload/0b4e3f"}`)
+					case r.Method == "POST" && r.URL.Path == "/transcript":
+						var request map[string]interface{}
+						json.NewDecoder(r.Body).Decode(&request)
+						if request["audio_url"] != "https://cdn.assemblyai.com/upload/0b4e3f" || request["language_detection"] != true {
+							t.Errorf("unexpected job request %v", request)
+						}
+						io.WriteString(w, `{"id": "6ph9b3xk2e", "status": "queued"}`)
+					case r.Method == "GET" && r.URL.Path == "/transcript/6ph9b3xk2e":
+						mu.Lock()
+						polls++
+						first := polls == 1
+						mu.Unlock()
+						if first {
+							io.WriteString(w, `{"id": "6ph9b3xk2e", "status": "processing"}`)
+							return
+						}
+						io.WriteString(w, assemblyAIPayload)
+					default:
+						t.Errorf("unexpected request %s %s", r.Method, r.URL)
+						w.WriteHeader(http.StatusNotFound)
+					}
+				}
+			},
+			newService: func(baseURL string) Service {
+				service := NewAssemblyAIService("aai-key")
+				service.Options.BaseURL = baseURL
+				service.PollInterval = time.Millisecond
+				return service
+			},
+			want: &models.Transcript{
+				Language: "en_us",
+				Duration: 6.2,
+				Text:     "Hello there. How are you?",
+				Segments: sentences,
+				Words:    punctuatedWords,
+				Source:   "assemblyai",
+			},
+		},
+		{
+			name: "openai-compatible verbose_json",
+			handler: func(t *testing.T) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Path != "/v1/audio/transcriptions" || r.Header.Get("Authorization") != "" {
+						t.Errorf("unexpected request %s with Authorization %q", r.URL, r.Header.Get("Authorization"))
+					}
+					fields := formFields(t, r)
+					want := map[string][]string{
+						"model":                     {"whisper-1"},
+						"response_format":           {"verbose_json"},
+						"prompt":                    {"Vocabulary: gRPC."},
+						"timestamp_granularities[]": {"segment", "word"},
+					}
+					if !reflect.DeepEqual(fields, want) {
+						t.Errorf("form fields = %v, want %v", fields, want)
+					}
+					io.WriteString(w, verboseJSONPayload)
+				}
+			},
+			newService: func(baseURL string) Service {
+				return NewOpenAICompatibleService(baseURL+"/v1/", "")
+			},
+			opts: Options{Vocabulary: []string{"gRPC"}},
+			want: &models.Transcript{
+				Language: "english",
+				Duration: 6.2,
+				Text:     "Hello there. How are you?",
+				Segments: []models.Segment{
+					{ID: 0, Start: 0, End: 0.8, Text: "Hello there.", AvgLogprob: -0.21, NoSpeechProb: 0.01},
+					{ID: 1, Start: 3, End: 3.9, Text: "How are you?", AvgLogprob: -0.18, NoSpeechProb: 0.02},
+				},
+				Words: []models.Word{
+					{Start: 0.08, End: 0.4, Word: "Hello"},
+					{Start: 0.4, End: 0.8, Word: "there"},
+					{Start: 3.1, End: 3.3, Word: "How"},
+					{Start: 3.3, End: 3.5, Word: "are"},
+					{Start: 3.5, End: 3.9, Word: "you"},
+				},
+				Source: "whisper",
+			},
+		},
+	}
+
+	diarizationServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, diarizationPayload)
+	}))
+	defer diarizationServer.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler(t))
+			defer server.Close()
+
+			audioPath := writeAudio(t)
+			transcript, err := tt.newService(server.URL).TranscribeAudio(context.Background(), audioPath, tt.opts)
+			if err != nil {
+				t.Fatalf("TranscribeAudio: %v", err)
+			}
+
+			if transcript.CreatedAt.IsZero() {
+				t.Error("CreatedAt is not set")
+			}
+			transcript.CreatedAt = time.Time{}
+			if !reflect.DeepEqual(transcript, tt.want) {
+				got, _ := json.MarshalIndent(transcript, "", "  ")
+				want, _ := json.MarshalIndent(tt.want, "", "  ")
+				t.Fatalf("transcript =\n%s\nwant\n%s", got, want)
+			}
+
+			// none of the providers label speakers, that comes from the diarizer
+			turns, err := NewHTTPDiarizer(diarizationServer.URL, "").Diarize(context.Background(), audioPath)
+			if err != nil {
+				t.Fatalf("Diarize: %v", err)
+			}
+			AssignSpeakers(transcript, turns)
+
+			var speakers []string
+			for _, segment := range transcript.Segments {
+				speakers = append(speakers, segment.Speaker)
+			}
+			if want := []string{"SPEAKER_00", "SPEAKER_01"}; !reflect.DeepEqual(speakers, want) {
+				t.Errorf("segment speakers = %v, want %v", speakers, want)
+			}
+			if want := map[string]string{"SPEAKER_00": "SPEAKER_00", "SPEAKER_01": "SPEAKER_01"}; !reflect.DeepEqual(transcript.Speakers, want) {
+				t.Errorf("speakers = %v, want %v", transcript.Speakers, want)
+			}
+		})
+	}
+}
+
+func TestProviderErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		newService func(baseURL string) Service
+		wantErr    string
+	}{
+		{
+			name: "assemblyai job failed",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/upload" {
+					io.WriteString(w, `{"upload_url": "https://cdn.assemblyai.com/upload/0b4e3f"}`)
+					return
+				}
+				io.WriteString(w, `{"id": "6ph9b3xk2e", "status": "error", "error": "Audio duration is too short."}`)
+			},
+			newService: func(baseURL string) Service {
+				service := NewAssemblyAIService("aai-key")
+				service.Options.BaseURL = baseURL
+				return service
+			},
+			wantErr: "transcription failed: Audio duration is too short.",
+		},
+		{
+			name: "deepgram bad request",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				io.WriteString(w, `{"err_code": "Bad Request", "err_msg": "Bad Request: failed to process audio: corrupt or unsupported data"}`)
+			},
+			newService: func(baseURL string) Service {
+				service := NewDeepgramService("dg-key")
+				service.Options.BaseURL = baseURL
+				return service
+			},
+			wantErr: "corrupt or unsupported data",
+		},
+		{
+			name: "openai-compatible bad request",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				io.WriteString(w, `{"error": {"message": "Invalid file format.", "type": "invalid_request_error"}}`)
+			},
+			newService: func(baseURL string) Service {
+				return NewOpenAICompatibleService(baseURL, "")
+			},
+			wantErr: "Invalid file format.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			_, err := tt.newService(server.URL).TranscribeAudio(context.Background(), writeAudio(t), Options{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTranslationUnsupported(t *testing.T) {
+	for _, service := range []Service{NewDeepgramService("key"), NewAssemblyAIService("key")} {
+		if _, err := service.TranslateAudio(context.Background(), "talk.mp3", Options{}); err != ErrTranslationUnsupported {
+			t.Errorf("%T: err = %v, want ErrTranslationUnsupported", service, err)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package transcription
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,102 +10,251 @@ import (
 	"mime/multipart"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/captions"
+	"github.com/ahmadbasyouni10/videogpt/pkg/httpclient"
 )
 
 // Service defines the interface for transcription services
+// opts override the service's defaults for a single request
 type Service interface {
-	TranscribeAudio(audioPath string) (string, error)
+	// TranscribeAudio transcribes the audio in its original language
+	TranscribeAudio(ctx context.Context, audioPath string, opts Options) (*models.Transcript, error)
+	// TranslateAudio transcribes the audio straight into English
+	// services that can't do this return ErrTranslationUnsupported
+	TranslateAudio(ctx context.Context, audioPath string, opts Options) (*models.Transcript, error)
 }
 
+// ErrTranslationUnsupported is returned by services that can only transcribe
+var ErrTranslationUnsupported = errors.New("translation is not supported by this transcription service")
+
+// MaxUploadBytes is the largest file the Whisper API accepts
+const MaxUploadBytes = 25 * 1024 * 1024
+
 // WhisperService implements the Service interface using OpenAI's Whisper API
+// it works with any server that implements the same endpoint, see Options.BaseURL
 type WhisperService struct {
 	APIKey  string
-	Timeout time.Duration
+	HTTP    *httpclient.Client // retries, backoff and typed errors
+	Options Options            // global defaults, merged under the per-request options
 }
 
 // NewWhisperService creates a new Whisper transcription service
 func NewWhisperService(apiKey string) *WhisperService {
 	return &WhisperService{
 		APIKey:  apiKey,
-		Timeout: 5 * time.Minute, // Default timeout of 5 minutes
+		HTTP:    httpclient.New(5 * time.Minute), // Default timeout of 5 minutes per attempt
+		Options: DefaultOptions(),
 	}
 }
 
-// WhisperResponse represents the response from OpenAI's Whisper API
+// WhisperResponse represents the verbose_json response from OpenAI's Whisper API
 type WhisperResponse struct {
-	Text string `json:"text"`
+	Text     string           `json:"text"`
+	Language string           `json:"language"`
+	Duration float64          `json:"duration"`
+	Segments []WhisperSegment `json:"segments"`
+	Words    []WhisperWord    `json:"words"`
+}
+
+// WhisperSegment is one segment of a verbose_json response
+type WhisperSegment struct {
+	ID           int     `json:"id"`
+	Start        float64 `json:"start"`
+	End          float64 `json:"end"`
+	Text         string  `json:"text"`
+	AvgLogprob   float64 `json:"avg_logprob"`
+	NoSpeechProb float64 `json:"no_speech_prob"`
+}
+
+// WhisperWord is one word of a verbose_json response, only sent when word timestamps are requested
+type WhisperWord struct {
+	Word  string  `json:"word"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// toTranscript converts the API response into our transcript model
+func (r *WhisperResponse) toTranscript() *models.Transcript {
+	transcript := &models.Transcript{
+		Language:  r.Language,
+		Duration:  r.Duration,
+		Text:      strings.TrimSpace(r.Text),
+		Segments:  make([]models.Segment, 0, len(r.Segments)),
+		Source:    "whisper",
+		CreatedAt: time.Now(),
+	}
+	for _, segment := range r.Segments {
+		transcript.Segments = append(transcript.Segments, models.Segment{
+			ID:           segment.ID,
+			Start:        segment.Start,
+			End:          segment.End,
+			Text:         strings.TrimSpace(segment.Text),
+			AvgLogprob:   segment.AvgLogprob,
+			NoSpeechProb: segment.NoSpeechProb,
+		})
+	}
+	for _, word := range r.Words {
+		transcript.Words = append(transcript.Words, models.Word{
+			Start: word.Start,
+			End:   word.End,
+			Word:  word.Word,
+		})
+	}
+	return transcript
 }
 
 // TranscribeAudio sends audio to OpenAI's Whisper API for transcription
-func (s *WhisperService) TranscribeAudio(audioPath string) (string, error) {
-	if s.APIKey == "" {
-		return "", errors.New("OpenAI API key is required")
+// files over MaxUploadBytes have to be split first, see TranscribeChunks
+func (s *WhisperService) TranscribeAudio(ctx context.Context, audioPath string, opts Options) (*models.Transcript, error) {
+	return s.send(ctx, "/audio/transcriptions", audioPath, s.Options.Merge(opts))
+}
+
+// TranslateAudio sends audio to OpenAI's Whisper API for translation into English
+// the returned transcript is in English with SourceLanguage set to what Whisper detected
+func (s *WhisperService) TranslateAudio(ctx context.Context, audioPath string, opts Options) (*models.Transcript, error) {
+	opts = s.Options.Merge(opts)
+
+	// the translations endpoint always outputs English and has no language parameter
+	sourceLanguage := opts.Language
+	opts.Language = ""
+
+	transcript, err := s.send(ctx, "/audio/translations", audioPath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// verbose_json reports the detected input language here, fall back to what the caller told us
+	if transcript.Language != "" && transcript.Language != "en" && transcript.Language != "english" {
+		sourceLanguage = transcript.Language
+	}
+	transcript.SourceLanguage = sourceLanguage
+	transcript.Language = "en"
+	return transcript, nil
+}
+
+// send uploads the audio to one of the Whisper endpoints and parses the response
+func (s *WhisperService) send(ctx context.Context, endpoint string, audioPath string, opts Options) (*models.Transcript, error) {
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultBaseURL
+	}
+
+	// self-hosted servers often run without auth, OpenAI never does
+	if s.APIKey == "" && opts.BaseURL == DefaultBaseURL {
+		return nil, errors.New("OpenAI API key is required")
 	}
 
 	file, err := os.Open(audioPath)
 	if err != nil {
-		return "", fmt.Errorf("failed to open audio file: %w", err)
+		return nil, fmt.Errorf("failed to open audio file: %w", err)
 	}
 	defer file.Close()
 
+	// fail fast instead of uploading 25 MB just to get a 413 back
+	if info, err := file.Stat(); err == nil && info.Size() > MaxUploadBytes {
+		return nil, fmt.Errorf("audio file is %d bytes, over the %d byte upload limit", info.Size(), MaxUploadBytes)
+	}
+
 	// Create a new HTTP request
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 
-	// Add the file to the request
-	part, err := writer.CreateFormFile("file", "audio.mp3")
+	// Add the file to the request, named after the real file so the server can tell the format
+	part, err := writer.CreateFormFile("file", filepath.Base(audioPath))
 	if err != nil {
-		return "", fmt.Errorf("failed to create form file: %w", err)
+		return nil, fmt.Errorf("failed to create form file: %w", err)
 	}
 
 	if _, err = io.Copy(part, file); err != nil {
-		return "", fmt.Errorf("failed to copy file to request: %w", err)
+		return nil, fmt.Errorf("failed to copy file to request: %w", err)
 	}
 
-	// Add the model parameter
-	if err = writer.WriteField("model", "whisper-1"); err != nil {
-		return "", fmt.Errorf("failed to add model field: %w", err)
+	// Add the model and the optional parameters
+	fields := [][2]string{
+		{"model", opts.Model},
+		{"response_format", opts.ResponseFormat},
+	}
+	if opts.Language != "" {
+		fields = append(fields, [2]string{"language", opts.Language})
+	}
+	if prompt := opts.FullPrompt(); prompt != "" {
+		fields = append(fields, [2]string{"prompt", prompt})
+	}
+	if opts.Temperature != nil {
+		fields = append(fields, [2]string{"temperature", strconv.FormatFloat(*opts.Temperature, 'f', -1, 64)})
+	}
+	// timestamp granularities only work with verbose_json on the transcriptions endpoint,
+	// asking for both adds word timings on top of segments
+	if opts.ResponseFormat == FormatVerboseJSON && endpoint == "/audio/transcriptions" {
+		fields = append(fields,
+			[2]string{"timestamp_granularities[]", "segment"},
+			[2]string{"timestamp_granularities[]", "word"},
+		)
+	}
+	for _, field := range fields {
+		if err = writer.WriteField(field[0], field[1]); err != nil {
+			return nil, fmt.Errorf("failed to add %s field: %w", field[0], err)
+		}
 	}
 
 	// Close the writer
 	if err = writer.Close(); err != nil {
-		return "", fmt.Errorf("failed to close writer: %w", err)
+		return nil, fmt.Errorf("failed to close writer: %w", err)
 	}
 
 	// Create the HTTP request
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/audio/transcriptions", body)
+	req, err := http.NewRequestWithContext(ctx, "POST", opts.BaseURL+endpoint, body)
 	if err != nil {
-		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
 	// Set headers
 	req.Header.Set("Content-Type", writer.FormDataContentType())
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.APIKey))
+	if s.APIKey != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.APIKey))
+	}
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: s.Timeout,
+	// Send the request, retrying rate limits and server errors
+	responseBody, err := s.HTTP.Do(req)
+	if err != nil {
+		return nil, err
 	}
 
-	// Send the request
-	resp, err := client.Do(req)
+	transcript, err := parseResponse(opts.ResponseFormat, responseBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	if transcript.Language == "" {
+		transcript.Language = opts.Language
+	}
+	return transcript, nil
+}
 
-	// Check the response status
-	if resp.StatusCode != http.StatusOK {
-		responseBody, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(responseBody))
+// parseResponse turns the response body into a transcript according to the format that was asked for
+// text and json have no timings, so they come back with text only and no segments
+func parseResponse(format string, body []byte) (*models.Transcript, error) {
+	switch format {
+	case FormatSRT, FormatVTT:
+		transcript, err := captions.Parse(captions.Format(format), body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s response: %w", format, err)
+		}
+		transcript.Source = "whisper"
+		return transcript, nil
+
+	case FormatText:
+		return (&WhisperResponse{Text: string(body)}).toTranscript(), nil
 	}
 
-	// Parse the response
+	// json and verbose_json
 	var result WhisperResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
-
-	return result.Text, nil
+	return result.toTranscript(), nil
 }
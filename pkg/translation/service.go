@@ -0,0 +1,169 @@
+// Package translation translates timed transcripts into other languages
+package translation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ahmadbasyouni10/videogpt/internal/models"
+	"github.com/ahmadbasyouni10/videogpt/pkg/chat"
+	"github.com/ahmadbasyouni10/videogpt/pkg/parallel"
+)
+
+// Service defines the interface for transcript translation services
+type Service interface {
+	// TranslateTranscript returns a copy of the transcript with every segment translated
+	// into targetLanguage, keeping the segment boundaries and timestamps
+	TranslateTranscript(ctx context.Context, transcript *models.Transcript, targetLanguage string) (*models.Transcript, error)
+}
+
+// ChatTranslator translates transcripts through the chat completions API
+// segments are sent in numbered batches so the model can't merge or split them
+type ChatTranslator struct {
+	Client      *chat.Client
+	Model       string
+	BatchSize   int // segments per request
+	Concurrency int // batches in flight at once
+}
+
+// NewChatTranslator creates a translator using the given chat client
+func NewChatTranslator(client *chat.Client) *ChatTranslator {
+	return &ChatTranslator{
+		Client:      client,
+		Model:       "gpt-3.5-turbo",
+		BatchSize:   40,
+		Concurrency: 4,
+	}
+}
+
+// batchItem is one segment in the JSON we send and expect back
+type batchItem struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+}
+
+// batchResponse is the JSON object the model is asked to return
+type batchResponse struct {
+	Translations []batchItem `json:"translations"`
+}
+
+// TranslateTranscript translates the transcript segment by segment
+// word timings don't survive translation, so the result only has segments
+func (t *ChatTranslator) TranslateTranscript(ctx context.Context, transcript *models.Transcript, targetLanguage string) (*models.Transcript, error) {
+	batchSize := t.BatchSize
+	if batchSize <= 0 {
+		batchSize = 40
+	}
+
+	translated := make([]string, len(transcript.Segments))
+	batches := (len(transcript.Segments) + batchSize - 1) / batchSize
+	err := parallel.Run(ctx, batches, t.Concurrency, func(ctx context.Context, i int) error {
+		start := i * batchSize
+		end := min(start+batchSize, len(transcript.Segments))
+
+		texts, err := t.translateBatch(ctx, transcript.Segments[start:end], transcript.Language, targetLanguage)
+		if err != nil {
+			return fmt.Errorf("segments %d-%d: %w", start, end-1, err)
+		}
+		copy(translated[start:end], texts)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sourceCreatedAt := transcript.CreatedAt
+	result := &models.Transcript{
+		VideoID:         transcript.VideoID,
+		Language:        targetLanguage,
+		SourceLanguage:  transcript.Language,
+		Duration:        transcript.Duration,
+		Segments:        make([]models.Segment, len(transcript.Segments)),
+		Source:          transcript.Source,
+		Speakers:        transcript.Speakers,
+		CreatedAt:       time.Now(),
+		SourceCreatedAt: &sourceCreatedAt,
+	}
+	for i, segment := range transcript.Segments {
+		segment.Text = translated[i]
+		result.Segments[i] = segment
+	}
+	result.Text = strings.Join(translated, " ")
+
+	return result, nil
+}
+
+// translateBatch translates one batch, retrying once if the model drops or invents ids
+func (t *ChatTranslator) translateBatch(ctx context.Context, segments []models.Segment, sourceLanguage string, targetLanguage string) ([]string, error) {
+	items := make([]batchItem, len(segments))
+	for i, segment := range segments {
+		items[i] = batchItem{ID: i, Text: segment.Text}
+	}
+	input, err := json.Marshal(items)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal segments: %w", err)
+	}
+
+	from := "the source language"
+	if sourceLanguage != "" {
+		from = fmt.Sprintf("language %q", sourceLanguage)
+	}
+
+	request := chat.Request{
+		Model: t.Model,
+		Messages: []chat.Message{
+			{
+				Role: "system",
+				Content: "You translate video subtitles. You receive a JSON array of numbered subtitle segments and reply with a JSON object " +
+					`{"translations": [{"id": <id>, "text": <translation>}]}` +
+					" containing exactly one entry per input id. Never merge, split, drop or reorder segments, and keep names and technical terms intact.",
+			},
+			{
+				Role:    "user",
+				Content: fmt.Sprintf("Translate these segments from %s into language %q:\n\n%s", from, targetLanguage, input),
+			},
+		},
+		Temperature:    0.2, // Low temperature keeps translations literal
+		ResponseFormat: &chat.ResponseFormat{Type: "json_object"},
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < 2; attempt++ {
+		content, err := t.Client.Complete(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		texts, err := parseBatch(content, len(segments))
+		if err == nil {
+			return texts, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// parseBatch checks the model returned exactly one translation per segment
+func parseBatch(content string, count int) ([]string, error) {
+	var response batchResponse
+	if err := json.Unmarshal([]byte(content), &response); err != nil {
+		return nil, fmt.Errorf("failed to decode translation: %w", err)
+	}
+
+	texts := make([]string, count)
+	seen := make([]bool, count)
+	for _, item := range response.Translations {
+		if item.ID < 0 || item.ID >= count || seen[item.ID] {
+			return nil, fmt.Errorf("translation has unexpected segment id %d", item.ID)
+		}
+		texts[item.ID] = strings.TrimSpace(item.Text)
+		seen[item.ID] = true
+	}
+	if len(response.Translations) != count {
+		return nil, fmt.Errorf("translation has %d segments, expected %d", len(response.Translations), count)
+	}
+	return texts, nil
+}
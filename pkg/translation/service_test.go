@@ -0,0 +1,70 @@
+package translation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "in order",
+			content: `{"translations": [{"id": 0, "text": " Hola. "}, {"id": 1, "text": "¿Qué tal?"}, {"id": 2, "text": "Adiós."}]}`,
+			want:    []string{"Hola.", "¿Qué tal?", "Adiós."},
+		},
+		{
+			name:    "out of order is put back by id",
+			content: `{"translations": [{"id": 2, "text": "c"}, {"id": 0, "text": "a"}, {"id": 1, "text": "b"}]}`,
+			want:    []string{"a", "b", "c"},
+		},
+		{
+			name:    "missing segment",
+			content: `{"translations": [{"id": 0, "text": "a"}, {"id": 2, "text": "c"}]}`,
+			wantErr: "translation has 2 segments, expected 3",
+		},
+		{
+			name:    "extra segment",
+			content: `{"translations": [{"id": 0, "text": "a"}, {"id": 1, "text": "b"}, {"id": 2, "text": "c"}, {"id": 3, "text": "d"}]}`,
+			wantErr: "unexpected segment id 3",
+		},
+		{
+			name:    "segment twice",
+			content: `{"translations": [{"id": 0, "text": "a"}, {"id": 1, "text": "b"}, {"id": 1, "text": "b"}]}`,
+			wantErr: "unexpected segment id 1",
+		},
+		{
+			name:    "negative id",
+			content: `{"translations": [{"id": -1, "text": "a"}]}`,
+			wantErr: "unexpected segment id -1",
+		},
+		{
+			name:    "not json",
+			content: "1. a\n2. b\n3. c",
+			wantErr: "failed to decode translation",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBatch(tt.content, 3)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseBatch: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("texts = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}